@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"github.com/gdamore/tcell/v2"
+	"ribbirc/client"
+)
+
+func (a *Application) drawTab(col int, index int, buffer string, title string, style tcell.Style) int {
+	text := fmt.Sprintf("[%d. %s]", index, title)
+
+	if buffer == a.pane.buffer {
+		style = a.theme.apply(style, "tab_active")
+	} else if channel, err := a.server.GetChannel(buffer); err == nil {
+		level, unread := channel.Activity()
+		if unread > 0 {
+			text = fmt.Sprintf("[%d. %s (%d)]", index, title, unread)
+		}
+		style = a.activityStyle(style, level)
+	}
+
+	a.drawString(col, a.height-2, text, style)
+	a.addHotspot(col, a.height-2, stringWidth(text), func(a *Application) {
+		a.closeView()
+		a.switchBuffer(buffer)
+	})
+	return col + stringWidth(text)
+}
+
+func (a *Application) activityStyle(style tcell.Style, level client.Activity) tcell.Style {
+	switch level {
+	case client.ActivityEvents:
+		return a.theme.apply(style, "activity_events")
+	case client.ActivityMessages:
+		return a.theme.apply(style, "activity_messages")
+	case client.ActivityHighlight:
+		return a.theme.apply(style, "activity_highlight")
+	}
+	return style
+}
+
+func (a *Application) nextActiveBuffer() {
+	channels := a.buffers.names
+	current := -1
+	for i, name := range channels {
+		if name == a.pane.buffer {
+			current = i
+		}
+	}
+
+	best, bestLevel := "", client.ActivityNone
+	for i := 1; i <= len(channels); i++ {
+		name := channels[(current+i+len(channels))%len(channels)]
+		channel, err := a.server.GetChannel(name)
+		if err != nil {
+			continue
+		}
+		if level, _ := channel.Activity(); level > bestLevel {
+			best, bestLevel = name, level
+		}
+	}
+
+	if bestLevel != client.ActivityNone {
+		a.switchBuffer(best)
+	}
+}
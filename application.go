@@ -3,31 +3,86 @@ package main
 import (
 	"fmt"
 	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/uniseg"
+	"os"
+	"os/signal"
 	"ribbirc/client"
+	"ribbirc/config"
 	"ribbirc/utils"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
 	"unicode"
 )
 
+const quitTimeout = 2 * time.Second
+
 type Application struct {
+	config   *config.Config
 	screen   tcell.Screen
 	width    int
 	height   int
-	listener chan int
-
-	server     *client.Server
-	channelTab string
-	logsOffset int
+	listener chan client.Event
+
+	server      *client.Server
+	layout      *layoutNode
+	pane        *pane
+	logsBuffer  []utils.Log
+	logsRun     []runEntry
+	clip        *area
+	hotspots    []hotspot
+	selection   *selection
+	theme       *theme
+	nickPalette []tcell.Color
+	memberNicks map[string]bool
+	views       map[string]view
+	buffers     *bufferList
+	filters     map[string]bufferFilter
+
+	keys       *keymap
+	keyPending []string
 
 	inputActive bool
 	inputCursor int
 	inputText   []rune
+
+	search   *searchState
+	mentions []jumpEntry
+
+	focused bool
+	muted   map[string]bool
+
+	showNickList    bool
+	completion      []string
+	completionStart int
+	completionIndex int
+
+	lastInput atomic.Int64
+	autoAway  atomic.Bool
+
+	running bool
+}
+
+type serverEvent struct {
+	tcell.EventTime
+	event client.Event
+}
+
+type signalEvent struct {
+	tcell.EventTime
 }
 
 func New() (*Application, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+
 	// @todo: temporary
-	listener := make(chan int)
-	server := client.New(listener, "irc.libera.chat", 6697, "ribbirc")
-	err := server.Connect()
+	listener := make(chan client.Event)
+	server := client.New(listener, cfg, "irc.libera.chat", 6697, "ribbirc")
+	err = server.Connect()
 	if err != nil {
 		return nil, err
 	}
@@ -37,11 +92,40 @@ func New() (*Application, error) {
 		return nil, err
 	}
 
-	return &Application{
+	a := &Application{
+		config:   cfg,
 		screen:   screen,
 		listener: listener,
 		server:   server,
-	}, nil
+		views:    make(map[string]view),
+		filters:  make(map[string]bufferFilter),
+
+		memberNicks: make(map[string]bool),
+
+		focused: true,
+		muted:   make(map[string]bool),
+
+		showNickList: true,
+	}
+	for _, buffer := range cfg.Notifications.Mute {
+		a.muted[strings.ToLower(buffer)] = true
+	}
+	var problems []string
+	a.buffers, err = loadBufferList(bufferListPath())
+	if err != nil {
+		server.GetLogger().Append("System", utils.LogError, fmt.Sprintf("Could not load buffer order: %s", err))
+	}
+	a.layout, err = loadLayout(layoutPath())
+	if err != nil {
+		server.GetLogger().Append("System", utils.LogError, fmt.Sprintf("Could not load layout: %s", err))
+	}
+	a.pane = a.layout.panes()[0]
+	a.keys, problems = newKeymap(cfg.Keys)
+	for _, problem := range problems {
+		server.GetLogger().Append("System", utils.LogError, fmt.Sprintf("Key binding: %s", problem))
+	}
+	a.lastInput.Store(time.Now().UnixNano())
+	return a, nil
 }
 
 func (a *Application) Run() error {
@@ -51,10 +135,19 @@ func (a *Application) Run() error {
 	}
 
 	a.screen.EnableMouse()
+	a.screen.EnableFocus()
+	if err := a.setTheme(a.config.Theme); err != nil {
+		a.server.GetLogger().Append("System", utils.LogError, fmt.Sprintf("Could not load theme: %s", err))
+		a.setTheme("dark")
+	}
 
 	go a.listenToChannel()
+	go a.watchIdle()
+	go a.watchSignals()
+	go a.tickClock()
 
-	for {
+	a.running = true
+	for a.running {
 		ev := a.screen.PollEvent()
 
 		switch ev := ev.(type) {
@@ -63,103 +156,135 @@ func (a *Application) Run() error {
 			a.screen.Sync()
 		case *tcell.EventMouse:
 			a.handleMouseEvent(ev)
+		case *tcell.EventFocus:
+			a.handleFocusEvent(ev)
 		case *tcell.EventKey:
 			a.handleKeyEvent(ev)
+		case *serverEvent:
+			a.handleServerEvent(ev.event)
+		case *signalEvent:
+			a.quit("")
 		}
 
-		a.draw()
+		if a.running {
+			a.draw()
+		}
 	}
+
+	a.Stop()
+	return nil
 }
 
 func (a *Application) Stop() {
 	a.screen.Fini()
 }
 
-func (a *Application) listenToChannel() {
-	for {
-		<-a.listener
-		a.draw()
+func (a *Application) quit(message string) {
+	if message == "" {
+		message = a.config.QuitMessage
 	}
+	a.saveLayout()
+	a.server.Quit(message, quitTimeout)
+	a.running = false
 }
 
-func (a *Application) handleMouseEvent(ev *tcell.EventMouse) {
-	if ev.Buttons()&tcell.WheelUp > 0 {
-		a.logsOffsetUp()
+func (a *Application) watchSignals() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	for range signals {
+		ev := &signalEvent{}
+		ev.SetEventNow()
+		a.screen.PostEventWait(ev)
 	}
+}
 
-	if ev.Buttons()&tcell.WheelDown > 0 {
-		a.logsOffsetDown()
+func (a *Application) listenToChannel() {
+	for event := range a.listener {
+		ev := &serverEvent{event: event}
+		ev.SetEventNow()
+		for a.screen.PostEvent(ev) != nil && event.Data != nil {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
+
+func (a *Application) handleServerEvent(event client.Event) {
+	switch data := event.Data.(type) {
+	case *client.WhoisInfo:
+		a.openView(event.Buffer, newWhoisView(data))
+	case *client.WhoResult:
+		a.openView(event.Buffer, newWhoView(data))
+	case *client.ListResult:
+		a.openView(event.Buffer, newListView(data))
+	case *client.HighlightEvent:
+		a.addMention(data)
+		a.notifyMessage(data.Buffer, data.Log)
+	case *client.MessageEvent:
+		a.notifyMessage(data.Buffer, data.Log)
 	}
 }
 
 func (a *Application) handleKeyEvent(ev *tcell.EventKey) {
-	if ev.Modifiers() == tcell.ModAlt {
-		indexes := map[rune]int{'0': 0, '1': 1, '2': 2, '3': 3, '4': 4, '5': 5, '6': 6, '7': 7, '8': 8, '9': 9}
-		channels := a.server.ChannelNames()
-		tab := indexes[ev.Rune()]
-		if tab == 0 {
-			a.channelTab = ""
-		} else if tab <= len(channels) {
-			a.channelTab = channels[tab-1]
+	a.markActive()
+
+	if v := a.currentView(); v != nil && len(a.keyPending) == 0 && ev.Modifiers()&(tcell.ModAlt|tcell.ModCtrl) == 0 {
+		if v.handleKey(a, ev) {
+			a.closeView()
 		}
-		a.logsOffset = 0
 		return
 	}
 
-	switch ev.Key() {
-	case tcell.KeyCtrlC:
-		a.Stop()
-		// @todo: end properly
-	case tcell.KeyEnter:
-		if len(a.inputText) == 0 {
-			a.inputActive = !a.inputActive
-		} else {
-			a.server.HandleUserInput(string(a.inputText), a.channelTab)
-			a.inputText = make([]rune, 0)
-			a.inputCursor = 0
-		}
-	case tcell.KeyPgUp:
-		a.logsOffsetUp()
-	case tcell.KeyPgDn:
-		a.logsOffsetDown()
-	default:
+	if a.handleSearchKey(ev) {
+		return
 	}
 
-	if a.inputActive {
-		switch ev.Key() {
-		case tcell.KeyBackspace:
-			if a.inputCursor > 0 {
-				a.inputText = append(a.inputText[:a.inputCursor-1], a.inputText[a.inputCursor:]...)
-				a.inputCursor--
-			}
-		case tcell.KeyLeft:
-			a.inputCursor--
-			if a.inputCursor < 0 {
-				a.inputCursor = 0
-			}
-		case tcell.KeyRight:
-			a.inputCursor++
-			if a.inputCursor > len(a.inputText) {
-				a.inputCursor = len(a.inputText)
-			}
-		default:
-			if unicode.IsPrint(ev.Rune()) {
-				a.inputText = append(a.inputText, ' ')
-				copy(a.inputText[a.inputCursor+1:], a.inputText[a.inputCursor:])
-				a.inputText[a.inputCursor] = ev.Rune()
-				a.inputCursor++
-			}
-		}
+	if a.handleKeymap(ev) {
+		return
+	}
+
+	if a.inputActive && ev.Key() == tcell.KeyRune && unicode.IsPrint(ev.Rune()) {
+		a.completion = nil
+		a.inputText = append(a.inputText, ' ')
+		copy(a.inputText[a.inputCursor+1:], a.inputText[a.inputCursor:])
+		a.inputText[a.inputCursor] = ev.Rune()
+		a.inputCursor++
+	}
+}
+
+func (a *Application) submitInput() {
+	if len(a.inputText) == 0 {
+		a.inputActive = !a.inputActive
+		return
+	}
+	a.handleInput(string(a.inputText))
+	a.inputText = make([]rune, 0)
+	a.inputCursor = 0
+}
+
+func (a *Application) inputBackspace() {
+	if a.inputCursor > 0 {
+		a.inputText = append(a.inputText[:a.inputCursor-1], a.inputText[a.inputCursor:]...)
+		a.inputCursor--
+	}
+}
+
+func (a *Application) switchToTab(tab int) {
+	if tab <= len(a.buffers.names) {
+		a.switchBuffer(a.bufferAt(tab))
 	}
 }
 
 func (a *Application) draw() {
-	defStyle := tcell.StyleDefault.Background(tcell.ColorReset).Foreground(tcell.ColorReset)
-	a.screen.SetStyle(defStyle)
+	a.screen.SetStyle(a.theme.style("default"))
 
 	a.screen.Clear()
 
-	a.drawLogs()
+	a.hotspots = a.hotspots[:0]
+	a.syncBuffers()
+	a.drawPanes()
+	a.drawSelection()
+	a.drawNickList()
+	a.drawView()
 	a.drawTopBar()
 	a.drawBottomBar()
 	a.drawInput()
@@ -168,7 +293,7 @@ func (a *Application) draw() {
 }
 
 func (a *Application) drawTopBar() {
-	style := tcell.StyleDefault.Background(tcell.ColorWhite).Foreground(tcell.ColorBlack)
+	style := a.theme.style("bar")
 
 	for col := range a.width {
 		a.screen.SetContent(col, 0, ' ', nil, style)
@@ -178,86 +303,87 @@ func (a *Application) drawTopBar() {
 	channel := a.currentChannel()
 	text := fmt.Sprintf("RibbIRC v0.1.0")
 	if channel != nil {
-		text += fmt.Sprintf(" / %s [%d users]", a.channelTab, len(channel.Nicks))
+		text += fmt.Sprintf(" / %s [%d users]", a.pane.buffer, channel.UserCount())
 		if channel.Topic != "" {
 			text += fmt.Sprintf(" - %s", channel.Topic)
 		}
+	} else if a.pane.buffer != "" {
+		text += fmt.Sprintf(" / %s (not joined)", a.pane.buffer)
 	}
 	a.drawString(0, 0, text, style)
 }
 
 func (a *Application) drawBottomBar() {
-	style := tcell.StyleDefault.Background(tcell.ColorWhite).Foreground(tcell.ColorBlack)
+	style := a.theme.style("bar")
 
 	for col := range a.width {
 		a.screen.SetContent(col, a.height-2, ' ', nil, style)
 		col++
 	}
 
-	text := "[0. Status]"
-	for i, channel := range a.server.ChannelNames() {
-		text += fmt.Sprintf(" [%d. %s]", i+1, channel)
-	}
-
-	a.drawString(0, a.height-2, text, style)
-}
-
-func (a *Application) drawLogs() {
-	var logs []utils.Log
-	channel := a.currentChannel()
-	if channel == nil {
-		logs = a.server.GetLogger().GetNLogs(a.height-3, a.logsOffset)
-	} else {
-		logs = channel.Logs.GetNLogs(a.height-3, a.logsOffset)
+	col := a.drawTab(0, 0, "", "Status", style)
+	for i, channel := range a.buffers.names {
+		col = a.drawTab(col+1, i+1, channel, channel, style)
 	}
 
-	row := a.height - 3
-	for i := len(logs) - 1; i >= 0; i-- {
-		height := a.drawLog(row, logs[i])
-		row -= height
+	if status := a.statusLine(); status != "" {
+		status = " " + status + " "
+		a.drawString(a.width-stringWidth(status), a.height-2, status, style)
 	}
 }
 
-func (a *Application) drawLog(row int, log utils.Log) int {
-	baseStyle := tcell.StyleDefault.Background(tcell.ColorReset)
+func (a *Application) drawLog(p *pane, row int, position int, log utils.Log) int {
+	style := a.theme.style("default")
+	if p == a.pane {
+		style = a.searchStyle(position, log, style)
+	}
+	style = a.theme.logStyle(style, log)
+	viewport := a.paneViewport(p)
+	x, width := viewport.x, viewport.width
 	height := 1
-	delimIndex := 16
+	indent := x + a.textIndent(log)
+	delim := x + logDelim
 
 	switch log.Kind {
 	case utils.LogPrivMsg:
-		style := baseStyle.Foreground(tcell.ColorReset)
-		height = a.drawStringWrap(delimIndex+2, row, log.Text, style)
-		a.drawString(delimIndex-len(log.Source)-1, row-height+1, log.Source, style)
+		spans := append(a.mentionSpans(log.Text, style), a.urlSpans(log.Text, style)...)
+		height = a.drawStringWrapSpans(indent, row, x+width-indent-1, log.Text, style, spans)
+		nickX := delim - stringWidth(log.Source) - 1
+		a.drawString(nickX, row-height+1, log.Source, a.nickStyle(style, log.Source))
+		a.addNickHotspot(nickX, row-height+1, log.Source, p.buffer)
 		for i := row - height + 1; i <= row; i++ {
-			a.drawString(delimIndex, i, "│", style)
+			a.drawString(delim, i, "│", style)
 		}
-	case utils.LogSystem:
-		style := baseStyle.Foreground(tcell.ColorBlue)
-		height = a.drawStringWrap(delimIndex+2, row, log.Text, style)
+	case utils.LogSystem, utils.LogNick:
+		height = a.drawStringWrapSpans(indent, row, x+width-indent-1, log.Text, style, a.urlSpans(log.Text, style))
 		for i := row - height + 1; i <= row; i++ {
-			a.drawString(delimIndex, i, "│", style)
+			a.drawString(delim, i, "│", style)
 		}
-	case utils.LogError:
-		style := baseStyle.Foreground(tcell.ColorRed)
-		height = a.drawStringWrap(len(log.Source)+2, row, log.Text, style)
-		a.drawString(0, row-height+1, fmt.Sprintf("%s:", log.Source), style)
-	case utils.LogStatus:
-		style := baseStyle.Foreground(tcell.ColorReset)
-		height = a.drawStringWrap(len(log.Source)+2, row, log.Text, style)
-		a.drawString(0, row-height+1, fmt.Sprintf("%s:", log.Source), style)
-	case utils.LogJoined:
-		style := baseStyle.Foreground(tcell.ColorGreen)
-		a.drawString(delimIndex, row, fmt.Sprintf("│ %s %s", log.Source, log.Text), style)
-	case utils.LogLeft:
-		style := baseStyle.Foreground(tcell.ColorRed)
-		a.drawString(delimIndex, row, fmt.Sprintf("│ %s %s", log.Source, log.Text), style)
+	case utils.LogError, utils.LogStatus:
+		height = a.drawStringWrapSpans(indent, row, x+width-indent-1, log.Text, style, a.urlSpans(log.Text, style))
+		a.drawString(x, row-height+1, fmt.Sprintf("%s:", log.Source), style)
+	case utils.LogJoined, utils.LogLeft:
+		col := a.drawStringSpans(delim, row, "│ ", 0, style, nil)
+		a.addNickHotspot(col, row, log.Source, p.buffer)
+		col = a.drawStringSpans(col, row, log.Source, 0, a.nickStyle(style, log.Source), nil)
+		a.drawString(col, row, " "+log.Text, style)
+	case utils.LogMarker:
+		text := fmt.Sprintf(" %s ", log.Text)
+		for col := x; col < x+width; col++ {
+			a.setContent(col, row, "─", style)
+		}
+		a.drawString(x+(width-stringWidth(text))/2, row, text, style)
 	}
 
 	return height
 }
 
 func (a *Application) drawInput() {
-	style := tcell.StyleDefault.Background(tcell.ColorReset).Foreground(tcell.ColorReset)
+	if a.drawSearchInput() {
+		return
+	}
+
+	style := a.theme.style("input")
 
 	a.drawString(0, a.height-1, string(a.inputText), style)
 
@@ -268,54 +394,62 @@ func (a *Application) drawInput() {
 	}
 }
 
-func (a *Application) drawString(x int, y int, text string, style tcell.Style) {
-	row := y
-	col := x
-	for _, r := range []rune(text) {
-		a.screen.SetContent(col, row, r, nil, style)
-		_, _, _, width := a.screen.GetContent(col, row)
-		col += width
+func (a *Application) setContent(col int, row int, cluster string, style tcell.Style) {
+	if a.clip != nil && !a.clip.contains(col, row) {
+		return
 	}
+	runes := []rune(cluster)
+	a.screen.SetContent(col, row, runes[0], runes[1:], style)
 }
 
-func (a *Application) drawStringWrap(x int, y int, text string, style tcell.Style) int {
-	chunks := make([]string, 0)
-	col := x
-	start := 0
-	for i, r := range []rune(text) {
-		a.screen.SetContent(col, 0, r, nil, style)
-		_, _, _, width := a.screen.GetContent(col, 0)
-		col += width
-		if col >= a.width-1 {
-			col = x
-			chunks = append(chunks, text[start:i])
-			start = i
+func (a *Application) drawString(x int, y int, text string, style tcell.Style) {
+	a.drawStringSpans(x, y, text, 0, style, nil)
+}
+
+func (a *Application) drawStringSpans(x int, y int, text string, offset int, style tcell.Style, spans []styleSpan) int {
+	col, i, state := x, 0, -1
+	for rest := text; len(rest) > 0; {
+		var cluster string
+		var boundaries int
+		cluster, rest, boundaries, state = uniseg.StepString(rest, state)
+		clusterStyle, link := style, ""
+		for _, span := range spans {
+			if offset+i >= span.start && offset+i < span.end {
+				clusterStyle, link = span.style, span.url
+			}
+		}
+		width := max(boundaries>>uniseg.ShiftWidth, 1)
+		a.setContent(col, y, cluster, clusterStyle)
+		if link != "" {
+			a.addHotspot(col, y, width, func(a *Application) { a.openURL(link) })
 		}
+		col += width
+		i += len(cluster)
 	}
-	chunks = append(chunks, text[start:])
+	return col
+}
 
-	for i, chunk := range chunks {
-		a.drawString(x, y+i-len(chunks)+1, chunk, style)
+func (a *Application) drawStringWrapSpans(x int, y int, width int, text string, style tcell.Style, spans []styleSpan) int {
+	starts := wrapOffsets(text, width)
+	for i, start := range starts {
+		end := len(text)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		a.drawStringSpans(x, y+i-len(starts)+1, text[start:end], start, style, spans)
 	}
 
-	return len(chunks)
+	return len(starts)
 }
 
 func (a *Application) currentChannel() *client.Channel {
-	channel, err := a.server.GetChannel(a.channelTab)
-	if err != nil {
-		a.channelTab = ""
-	}
+	channel, _ := a.server.GetChannel(a.pane.buffer)
 	return channel
 }
 
-func (a *Application) logsOffsetUp() {
-	a.logsOffset += 3
-}
-
-func (a *Application) logsOffsetDown() {
-	a.logsOffset -= 3
-	if a.logsOffset < 0 {
-		a.logsOffset = 0
-	}
+func (a *Application) switchBuffer(name string) {
+	a.pane.buffer = name
+	a.pane.offset = 0
+	a.stopSearch()
+	a.server.SetActiveBuffer(name)
 }
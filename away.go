@@ -0,0 +1,31 @@
+package main
+
+import (
+	"time"
+)
+
+func (a *Application) markActive() {
+	a.lastInput.Store(time.Now().UnixNano())
+	if a.autoAway.CompareAndSwap(true, false) {
+		a.server.SetAway("")
+	}
+}
+
+func (a *Application) watchIdle() {
+	idle := time.Duration(a.config.AutoAway.IdleMinutes) * time.Minute
+	if idle <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(time.Minute / 4)
+	defer ticker.Stop()
+	for range ticker.C {
+		lastInput := time.Unix(0, a.lastInput.Load())
+		if away, _ := a.server.Away(); away || time.Since(lastInput) < idle {
+			continue
+		}
+		if a.autoAway.CompareAndSwap(false, true) {
+			a.server.SetAway(a.config.AutoAway.Message)
+		}
+	}
+}
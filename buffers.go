@@ -0,0 +1,252 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"ribbirc/config"
+	"ribbirc/utils"
+	"strconv"
+	"strings"
+)
+
+type bufferList struct {
+	path  string
+	names []string
+	saved []string
+}
+
+func loadBufferList(path string) (*bufferList, error) {
+	l := &bufferList{path: path, names: make([]string, 0), saved: make([]string, 0)}
+	if path == "" {
+		return l, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return l, err
+	}
+	return l, json.Unmarshal(data, &l.saved)
+}
+
+func (l *bufferList) save() error {
+	if l.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(l.saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(l.path), 0o755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(l.path, data, 0o644)
+}
+
+func bufferListPath() string {
+	dir, err := config.DataDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "buffers.json")
+}
+
+func indexOf(names []string, name string) int {
+	for i, other := range names {
+		if strings.EqualFold(other, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (l *bufferList) sync(open []string) {
+	names := l.names[:0]
+	for _, name := range l.names {
+		if indexOf(open, name) >= 0 {
+			names = append(names, name)
+		}
+	}
+	l.names = names
+
+	for _, name := range open {
+		if indexOf(l.names, name) < 0 {
+			l.insert(name)
+		}
+	}
+}
+
+func (l *bufferList) insert(name string) {
+	rank := indexOf(l.saved, name)
+	if rank < 0 {
+		l.names = append(l.names, name)
+		return
+	}
+	for i, other := range l.names {
+		if otherRank := indexOf(l.saved, other); otherRank < 0 || otherRank > rank {
+			l.names = append(l.names[:i], append([]string{name}, l.names[i:]...)...)
+			return
+		}
+	}
+	l.names = append(l.names, name)
+}
+
+func (l *bufferList) move(name string, position int) {
+	current := indexOf(l.names, name)
+	if current < 0 {
+		return
+	}
+	position = min(max(position, 0), len(l.names)-1)
+	l.names = append(l.names[:current], l.names[current+1:]...)
+	l.names = append(l.names[:position], append([]string{name}, l.names[position:]...)...)
+
+	saved := append([]string{}, l.names...)
+	for _, other := range l.saved {
+		if indexOf(saved, other) < 0 {
+			saved = append(saved, other)
+		}
+	}
+	l.saved = saved
+}
+
+func (l *bufferList) find(name string) (string, bool) {
+	if name == "" || strings.EqualFold(name, "status") {
+		return "", true
+	}
+	if i := indexOf(l.names, name); i >= 0 {
+		return l.names[i], true
+	}
+	for _, other := range l.names {
+		if strings.HasPrefix(strings.ToLower(other), strings.ToLower(name)) {
+			return other, true
+		}
+	}
+	return "", false
+}
+
+func (a *Application) syncBuffers() {
+	a.buffers.sync(a.server.ChannelNames())
+}
+
+func (a *Application) bufferIndex(name string) int {
+	if name == "" {
+		return 0
+	}
+	return indexOf(a.buffers.names, name) + 1
+}
+
+func (a *Application) bufferAt(index int) string {
+	if index <= 0 || index > len(a.buffers.names) {
+		return ""
+	}
+	return a.buffers.names[index-1]
+}
+
+func (a *Application) cycleBuffer(step int) {
+	count := len(a.buffers.names) + 1
+	a.switchBuffer(a.bufferAt((a.bufferIndex(a.pane.buffer) + step + count) % count))
+}
+
+func (a *Application) closeBuffer(name string) {
+	if name == "" {
+		a.server.GetLogger().Append("System", utils.LogError, "The status buffer cannot be closed.")
+		return
+	}
+	index := a.bufferIndex(name)
+	err := a.server.CloseBuffer(name)
+	if err != nil {
+		a.server.GetLogger().Append("System", utils.LogError, err.Error())
+		return
+	}
+	delete(a.views, name)
+	delete(a.filters, strings.ToLower(name))
+	a.syncBuffers()
+	for _, p := range a.layout.panes() {
+		if p.buffer == name && p != a.pane {
+			p.buffer, p.offset = "", 0
+		}
+	}
+	if name == a.pane.buffer {
+		a.switchBuffer(a.bufferAt(min(index, len(a.buffers.names))))
+	}
+}
+
+func (a *Application) clearBuffer() {
+	logs := a.bufferLogs(a.pane.buffer)
+	if logs == nil {
+		a.server.GetLogger().Append("System", utils.LogError, fmt.Sprintf(notJoinedFmt, a.pane.buffer))
+		return
+	}
+	logs.Clear()
+	a.pane.offset = 0
+	a.stopSearch()
+}
+
+func (a *Application) windowCommand(args []string) {
+	logs := a.server.GetLogger()
+	if len(args) == 0 || (len(args) < 2 && (args[0] == "move" || args[0] == "goto")) {
+		logs.Append("System", utils.LogError, "Invalid command format, expected '/window move <n>|goto <name>|split [buffer]|vsplit [buffer]|close|only|next|prev'.")
+		return
+	}
+
+	switch args[0] {
+	case "split", "vsplit":
+		direction := splitHorizontal
+		if args[0] == "vsplit" {
+			direction = splitVertical
+		}
+		name := a.pane.buffer
+		if len(args) > 1 {
+			var ok bool
+			name, ok = a.buffers.find(strings.Join(args[1:], " "))
+			if !ok {
+				logs.Append("System", utils.LogError, fmt.Sprintf("No buffer named %s.", strings.Join(args[1:], " ")))
+				return
+			}
+		}
+		a.splitPane(direction, name)
+	case "close":
+		a.closePane()
+	case "only":
+		a.onlyPane()
+	case "next", "prev":
+		step := 1
+		if args[0] == "prev" {
+			step = -1
+		}
+		a.cyclePane(step)
+	case "move":
+		position, err := strconv.Atoi(args[1])
+		if err != nil || position < 1 {
+			logs.Append("System", utils.LogError, fmt.Sprintf("Invalid buffer number %s.", args[1]))
+			return
+		}
+		if a.pane.buffer == "" {
+			logs.Append("System", utils.LogError, "The status buffer cannot be moved.")
+			return
+		}
+		if indexOf(a.buffers.names, a.pane.buffer) < 0 {
+			logs.Append("System", utils.LogError, fmt.Sprintf("%s is not in the buffer list.", a.pane.buffer))
+			return
+		}
+		a.buffers.move(a.pane.buffer, position-1)
+		if err := a.buffers.save(); err != nil {
+			logs.Append("System", utils.LogError, fmt.Sprintf("Could not save buffer order: %s", err))
+		}
+	case "goto":
+		name, ok := a.buffers.find(strings.Join(args[1:], " "))
+		if !ok {
+			logs.Append("System", utils.LogError, fmt.Sprintf("No buffer named %s.", strings.Join(args[1:], " ")))
+			return
+		}
+		a.switchBuffer(name)
+	default:
+		logs.Append("System", utils.LogError, fmt.Sprintf("Unknown window command %s.", args[0]))
+	}
+}
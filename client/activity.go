@@ -0,0 +1,82 @@
+package client
+
+import (
+	"ribbirc/utils"
+	"sync"
+)
+
+type Activity int
+
+const (
+	ActivityNone Activity = iota
+	ActivityEvents
+	ActivityMessages
+	ActivityHighlight
+)
+
+type activity struct {
+	mutex  sync.Mutex
+	level  Activity
+	unread int
+}
+
+func (c *Channel) Activity() (Activity, int) {
+	c.activity.mutex.Lock()
+	defer c.activity.mutex.Unlock()
+
+	return c.activity.level, c.activity.unread
+}
+
+func (c *Channel) markActivity(level Activity, unread bool) {
+	c.activity.mutex.Lock()
+	defer c.activity.mutex.Unlock()
+
+	c.activity.level = max(c.activity.level, level)
+	if unread {
+		c.activity.unread++
+	}
+}
+
+func (c *Channel) clearActivity() {
+	c.activity.mutex.Lock()
+	defer c.activity.mutex.Unlock()
+
+	c.activity.level = ActivityNone
+	c.activity.unread = 0
+}
+
+func (s *Server) SetActiveBuffer(name string) {
+	s.mutex.Lock()
+	s.active = name
+	channel, ok := s.channelsJoined[name]
+	s.mutex.Unlock()
+
+	if ok {
+		channel.clearActivity()
+	}
+}
+
+func (s *Server) trackActivity(channel *Channel) {
+	channel.Logs.SetListener(func(log utils.Log) {
+		s.mutex.Lock()
+		active := s.active == channel.Name
+		s.mutex.Unlock()
+
+		if active || log.Source == s.nick {
+			return
+		}
+
+		switch log.Kind {
+		case utils.LogPrivMsg:
+			if log.Highlight || channel.Query {
+				channel.markActivity(ActivityHighlight, true)
+			} else {
+				channel.markActivity(ActivityMessages, true)
+			}
+		case utils.LogJoined, utils.LogLeft, utils.LogNick, utils.LogSystem:
+			channel.markActivity(ActivityEvents, false)
+		case utils.LogError, utils.LogStatus:
+			channel.markActivity(ActivityMessages, false)
+		}
+	})
+}
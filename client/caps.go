@@ -0,0 +1,132 @@
+package client
+
+import (
+	"ribbirc/utils"
+	"strings"
+	"sync"
+)
+
+var wantedCaps = []string{
+	"account-notify",
+	"away-notify",
+	"batch",
+	"chghost",
+	"extended-join",
+	"labeled-response",
+	"multi-prefix",
+	"userhost-in-names",
+}
+
+type capabilities struct {
+	mutex       sync.Mutex
+	negotiating bool
+	available   map[string]string
+	enabled     map[string]bool
+}
+
+func newCapabilities() *capabilities {
+	return &capabilities{
+		available: make(map[string]string),
+		enabled:   make(map[string]bool),
+	}
+}
+
+func (c *capabilities) has(name string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.enabled[name]
+}
+
+func (c *capabilities) addAvailable(tokens string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, token := range strings.Fields(tokens) {
+		name, value, _ := strings.Cut(token, "=")
+		c.available[name] = value
+	}
+}
+
+func (c *capabilities) removeAvailable(tokens string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, name := range strings.Fields(tokens) {
+		delete(c.available, name)
+		delete(c.enabled, name)
+	}
+}
+
+func (c *capabilities) wanted() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	names := make([]string, 0)
+	for _, name := range wantedCaps {
+		if _, ok := c.available[name]; ok && !c.enabled[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (c *capabilities) acknowledge(tokens string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, name := range strings.Fields(tokens) {
+		if name[0] == '-' {
+			delete(c.enabled, name[1:])
+		} else {
+			c.enabled[name] = true
+		}
+	}
+}
+
+func (s *Server) handleCap(message *utils.Message) {
+	// <client> <subcommand> [*] :<capabilities>
+	subcommand := message.Parameters[1]
+	tokens := message.Parameters[len(message.Parameters)-1]
+	more := len(message.Parameters) > 3 && message.Parameters[2] == "*"
+
+	switch subcommand {
+	case "LS":
+		s.caps.addAvailable(tokens)
+		if !more {
+			s.requestCaps()
+		}
+	case "NEW":
+		s.caps.addAvailable(tokens)
+		s.requestCaps()
+	case "DEL":
+		s.caps.removeAvailable(tokens)
+	case "ACK":
+		s.caps.acknowledge(tokens)
+		if !more {
+			s.endCapNegotiation()
+		}
+	case "NAK":
+		s.endCapNegotiation()
+	}
+}
+
+func (s *Server) requestCaps() {
+	names := s.caps.wanted()
+	if len(names) == 0 {
+		s.endCapNegotiation()
+		return
+	}
+	s.SendMessage(&utils.Message{Command: "CAP", Parameters: []string{"REQ", strings.Join(names, " ")}})
+}
+
+func (s *Server) endCapNegotiation() {
+	s.caps.mutex.Lock()
+	negotiating := s.caps.negotiating
+	s.caps.negotiating = false
+	s.caps.mutex.Unlock()
+
+	if negotiating {
+		s.SendMessage(&utils.Message{Command: "CAP", Parameters: []string{"END"}})
+	}
+}
@@ -3,80 +3,216 @@ package client
 import (
 	"fmt"
 	"ribbirc/utils"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
 type Channel struct {
 	Name  string
 	Topic string
+	Query bool
 
-	mutex sync.Mutex
-	Logs  *utils.Logger
-	Nicks map[string]bool
+	mutex    sync.Mutex
+	Logs     *utils.Logger
+	members  map[string]string
+	prefixes string
+
+	awayNotice  string
+	activity    activity
+	spoke       map[string]time.Time
+	smartWindow time.Duration
+	self        func() string
+}
+
+type Member struct {
+	Nick   string
+	Prefix string
 }
 
-func newChannel(name string) *Channel {
+func newChannel(name string, prefixes string, scrollback int) *Channel {
 	return &Channel{
-		Name:  name,
-		Logs:  utils.NewLogger(),
-		Nicks: make(map[string]bool),
+		Name:     name,
+		Logs:     utils.NewLogger(scrollback),
+		members:  make(map[string]string),
+		prefixes: prefixes,
+		spoke:    make(map[string]time.Time),
+	}
+}
+
+func (c *Channel) UserCount() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return len(c.members)
+}
+
+func (c *Channel) Members() []Member {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	members := make([]Member, 0, len(c.members))
+	for nick, prefix := range c.members {
+		members = append(members, Member{nick, prefix})
+	}
+	sort.Slice(members, func(i, j int) bool {
+		ri, rj := c.rank(members[i].Prefix), c.rank(members[j].Prefix)
+		if ri != rj {
+			return ri < rj
+		}
+		return strings.ToLower(members[i].Nick) < strings.ToLower(members[j].Nick)
+	})
+	return members
+}
+
+func (c *Channel) rank(prefix string) int {
+	if prefix == "" {
+		return len(c.prefixes)
+	}
+	return strings.IndexByte(c.prefixes, prefix[0])
+}
+
+func (c *Channel) hasMember(nick string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	_, ok := c.members[nick]
+	return ok
+}
+
+func newQuery(nick string, scrollback int) *Channel {
+	query := newChannel(nick, "", scrollback)
+	query.Query = true
+	return query
+}
+
+func (c *Channel) userMessage(nick string, text string, highlight bool) (utils.Log, int) {
+	log := utils.Log{Time: time.Now(), Source: nick, Kind: utils.LogPrivMsg, Text: text, Highlight: highlight}
+
+	c.mutex.Lock()
+	c.spoke[nick] = log.Time
+	c.mutex.Unlock()
+
+	return log, c.Logs.AppendLog(log)
+}
+
+func (c *Channel) quiet(nick string) bool {
+	if c.self != nil && c.self() == nick {
+		return false
 	}
+	last, ok := c.spoke[nick]
+	return !ok || time.Since(last) > c.smartWindow
+}
+
+func (c *Channel) membershipLog(nick string, kind utils.LogKind, text string) {
+	c.Logs.AppendLog(utils.Log{Time: time.Now(), Source: nick, Kind: kind, Text: text, Filtered: c.quiet(nick)})
 }
 
-func (c *Channel) userMessage(nick string, text string) {
-	c.Logs.Append(nick, utils.LogPrivMsg, text)
+func (c *Channel) userAway(nick string, message string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.awayNotice == message {
+		return
+	}
+	c.awayNotice = message
+	if message != "" {
+		c.Logs.Append("*", utils.LogSystem, fmt.Sprintf("%s is away: %s", nick, message))
+	}
+}
+
+func (c *Channel) userJoin(nick string, show bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.members[nick] = ""
+	if show {
+		c.membershipLog(nick, utils.LogJoined, "joined.")
+	}
 }
 
-func (c *Channel) userJoin(nick string) {
+func (c *Channel) usersJoin(members []Member) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	c.Nicks[nick] = true
-	// @todo: handle prefixes
-	c.Logs.Append(nick, utils.LogJoined, "joined.")
+	for _, member := range members {
+		c.members[member.Nick] = member.Prefix
+	}
 }
 
-func (c *Channel) usersJoin(nicks []string) {
+func (c *Channel) userMode(nick string, symbol byte, add bool) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	for _, nick := range nicks {
-		c.Nicks[nick] = true
-		// @todo: handle prefixes
+	prefix, ok := c.members[nick]
+	if !ok {
+		return
 	}
+	prefix = strings.ReplaceAll(prefix, string(symbol), "")
+	if add {
+		prefix += string(symbol)
+	}
+	symbols := []byte(prefix)
+	sort.Slice(symbols, func(i, j int) bool {
+		return strings.IndexByte(c.prefixes, symbols[i]) < strings.IndexByte(c.prefixes, symbols[j])
+	})
+	c.members[nick] = string(symbols)
 }
 
-func (c *Channel) userLeave(nick string, reason string) {
+func (c *Channel) userLeave(nick string, reason string, show bool) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	if _, ok := c.Nicks[nick]; ok {
-		delete(c.Nicks, nick)
+	if _, ok := c.members[nick]; ok {
+		delete(c.members, nick)
+		if !show {
+			return
+		}
 		text := "left."
 		if reason != "" {
 			text = fmt.Sprintf("left. <%s>", reason)
 		}
-		c.Logs.Append(nick, utils.LogLeft, text)
+		c.membershipLog(nick, utils.LogLeft, text)
+		delete(c.spoke, nick)
 	}
 }
 
-func (c *Channel) userPart(nick string, reason string) {
-	c.userLeave(nick, reason)
+func (c *Channel) userPart(nick string, reason string, show bool) {
+	c.userLeave(nick, reason, show)
 }
 
-func (c *Channel) userQuit(nick string, reason string) {
-	c.userLeave(nick, reason)
+func (c *Channel) userQuit(nick string, reason string, show bool) {
+	c.userLeave(nick, reason, show)
+}
+
+func (c *Channel) userKick(nick string, by string, reason string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, ok := c.members[nick]; ok {
+		delete(c.members, nick)
+		text := fmt.Sprintf("was kicked by %s.", by)
+		if reason != "" {
+			text = fmt.Sprintf("was kicked by %s. <%s>", by, reason)
+		}
+		c.Logs.Append(nick, utils.LogLeft, text)
+		delete(c.spoke, nick)
+	}
 }
 
 func (c *Channel) userNick(oldNick string, newNick string) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	if _, ok := c.Nicks[oldNick]; ok {
-		// @todo: handle prefixes
-		delete(c.Nicks, oldNick)
-		c.Nicks[newNick] = true
+	if prefix, ok := c.members[oldNick]; ok {
+		delete(c.members, oldNick)
+		c.members[newNick] = prefix
 		text := fmt.Sprintf("%s changed their nick to %s.", oldNick, newNick)
-		c.Logs.Append(oldNick, utils.LogSystem, text)
+		c.membershipLog(oldNick, utils.LogNick, text)
+		if last, ok := c.spoke[oldNick]; ok {
+			delete(c.spoke, oldNick)
+			c.spoke[newNick] = last
+		}
 	}
 }
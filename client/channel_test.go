@@ -0,0 +1,59 @@
+package client
+
+import (
+	"ribbirc/utils"
+	"testing"
+	"time"
+)
+
+func TestChannelSmartFilter(t *testing.T) {
+	channel := newChannel("#chan", "@+", 100)
+	channel.smartWindow = time.Minute
+	channel.self = func() string {
+		return "me"
+	}
+
+	channel.userJoin("me", true)
+	channel.userJoin("alice", true)
+	channel.userJoin("bob", true)
+	channel.userMessage("alice", "hello", false)
+	channel.userNick("alice", "alice2")
+	channel.userPart("alice2", "", true)
+	channel.userQuit("bob", "", true)
+
+	tests := []struct {
+		kind     utils.LogKind
+		source   string
+		filtered bool
+	}{
+		{utils.LogJoined, "me", false},
+		{utils.LogJoined, "alice", true},
+		{utils.LogJoined, "bob", true},
+		{utils.LogPrivMsg, "alice", false},
+		{utils.LogNick, "alice", false},
+		{utils.LogLeft, "alice2", false},
+		{utils.LogLeft, "bob", true},
+	}
+
+	logs := channel.Logs.GetAllLogs()
+	if len(logs) != len(tests) {
+		t.Fatalf("Expected %d logs, got %d", len(tests), len(logs))
+	}
+
+	fails := 0
+	for i, test := range tests {
+		t.Logf("Running test %d...", i)
+
+		log := logs[i]
+		if log.Kind == test.kind && log.Source == test.source && log.Filtered == test.filtered {
+			t.Logf("  PASS")
+		} else {
+			t.Logf("  FAIL: Expected %d %s %t, got %d %s %t", test.kind, test.source, test.filtered, log.Kind, log.Source, log.Filtered)
+			fails++
+		}
+	}
+
+	if fails > 0 {
+		t.Fatalf("Failed %d/%d tests", fails, len(tests))
+	}
+}
@@ -23,12 +23,6 @@ func (s *Server) handleCommand(input string, channel string) *utils.Message {
 		message.Command = "PING"
 		message.Parameters = []string{strconv.FormatInt(time.Now().UnixMilli(), 10)}
 
-	case "/quit":
-		message.Command = "QUIT"
-		if paramCount == 1 {
-			message.Parameters = []string{strings.Join(parts[1:], " ")}
-		}
-
 	case "/nick":
 		if paramCount != 1 {
 			s.invalidCommandParameters("/nick <nickname>")
@@ -54,6 +48,7 @@ func (s *Server) handleCommand(input string, channel string) *utils.Message {
 		if paramCount == 1 {
 			message.Parameters = []string{parts[1]}
 		}
+		s.expectReply(message, "motd", "", s.logReply)
 
 	case "/version":
 		if paramCount > 1 {
@@ -116,6 +111,7 @@ func (s *Server) handleCommand(input string, channel string) *utils.Message {
 		if paramCount == 2 {
 			message.Parameters = append(message.Parameters, parts[2])
 		}
+		s.expectReply(message, "stats", "", s.logReply)
 
 	case "/help":
 		if paramCount > 1 {
@@ -126,6 +122,7 @@ func (s *Server) handleCommand(input string, channel string) *utils.Message {
 		if paramCount == 1 {
 			message.Parameters = []string{parts[1]}
 		}
+		s.expectReply(message, "help", "", s.logReply)
 
 	case "/info":
 		if paramCount > 0 {
@@ -133,6 +130,7 @@ func (s *Server) handleCommand(input string, channel string) *utils.Message {
 			return nil
 		}
 		message.Command = "INFO"
+		s.expectReply(message, "info", "", s.logReply)
 
 	case "/join":
 		if paramCount < 1 || paramCount > 2 {
@@ -177,14 +175,11 @@ func (s *Server) handleCommand(input string, channel string) *utils.Message {
 		message.Parameters = []string{parts[1]}
 
 	case "/list":
-		if paramCount > 1 {
+		if paramCount > 2 {
 			s.invalidCommandParameters("/list [<channel>{,<channel>}] [<elistcond>{,<elistcond>}]")
 			return nil
 		}
-		message.Command = "LIST"
-		if paramCount == 1 {
-			message.Parameters = []string{parts[1]}
-		}
+		return s.listCommand(parts[1:], channel)
 
 	case "/invite":
 		if paramCount != 2 {
@@ -212,6 +207,9 @@ func (s *Server) handleCommand(input string, channel string) *utils.Message {
 		}
 		message.Command = "WHO"
 		message.Parameters = []string{parts[1]}
+		s.expectReply(message, "who", parts[1], func(reply *Reply) {
+			s.notify(Event{Buffer: channel, Data: newWhoResult(reply)})
+		})
 
 	case "/whois":
 		if paramCount < 1 || paramCount > 2 {
@@ -223,6 +221,9 @@ func (s *Server) handleCommand(input string, channel string) *utils.Message {
 		if paramCount > 1 {
 			message.Parameters = append(message.Parameters, parts[2])
 		}
+		s.expectReply(message, "whois", parts[paramCount], func(reply *Reply) {
+			s.notify(Event{Buffer: channel, Data: newWhoisInfo(reply)})
+		})
 
 	case "/whowas":
 		if paramCount < 1 || paramCount > 2 {
@@ -234,6 +235,7 @@ func (s *Server) handleCommand(input string, channel string) *utils.Message {
 		if paramCount > 1 {
 			message.Parameters = append(message.Parameters, parts[2])
 		}
+		s.expectReply(message, "whowas", parts[1], s.logReply)
 
 	case "/kill":
 		if paramCount < 2 {
@@ -265,11 +267,43 @@ func (s *Server) handleCommand(input string, channel string) *utils.Message {
 		message.Command = "SQUIT"
 		message.Parameters = []string{parts[1], strings.Join(parts[2:], " ")}
 
+	case "/ignore":
+		s.ignoreCommand(parts, channel)
+		return nil
+
+	case "/unignore":
+		s.unignoreCommand(parts)
+		return nil
+
 	case "/away":
-		message.Command = "AWAY"
-		if paramCount > 0 {
-			message.Parameters = []string{strings.Join(parts[1:], " ")}
+		s.SetAway(strings.Join(parts[1:], " "))
+		return nil
+
+	case "/msg":
+		if paramCount < 2 {
+			s.invalidCommandParameters("/msg <target> <text>")
+			return nil
+		}
+		message.Command = "PRIVMSG"
+		message.Parameters = []string{parts[1], strings.Join(parts[2:], " ")}
+		if !strings.ContainsRune(s.iSupport.chantypes, rune(parts[1][0])) {
+			s.query(parts[1]).Logs.Append(s.nick, utils.LogPrivMsg, message.Parameters[1])
+		} else if target := s.channel(parts[1]); target != nil {
+			target.Logs.Append(s.nick, utils.LogPrivMsg, message.Parameters[1])
+		}
+
+	case "/query":
+		if paramCount < 1 {
+			s.invalidCommandParameters("/query <nick> [<text>]")
+			return nil
+		}
+		query := s.query(parts[1])
+		if paramCount == 1 {
+			return nil
 		}
+		message.Command = "PRIVMSG"
+		message.Parameters = []string{parts[1], strings.Join(parts[2:], " ")}
+		query.Logs.Append(s.nick, utils.LogPrivMsg, message.Parameters[1])
 
 	case "/links":
 		if paramCount > 0 {
@@ -277,6 +311,7 @@ func (s *Server) handleCommand(input string, channel string) *utils.Message {
 			return nil
 		}
 		message.Command = "LINKS"
+		s.expectReply(message, "links", "", s.logReply)
 
 	case "/userhost":
 		if paramCount > 5 {
@@ -0,0 +1,19 @@
+package client
+
+import (
+	"ribbirc/utils"
+)
+
+type Event struct {
+	Buffer string
+	Data   interface{}
+}
+
+type MessageEvent struct {
+	Buffer string
+	Log    utils.Log
+}
+
+func (s *Server) notify(event Event) {
+	s.listener <- event
+}
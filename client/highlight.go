@@ -0,0 +1,88 @@
+package client
+
+import (
+	"fmt"
+	"regexp"
+	"ribbirc/config"
+	"ribbirc/utils"
+	"strings"
+)
+
+const (
+	nickStart = `(?:^|[^\w\[\]\\^{}|` + "`" + `-])`
+	nickEnd   = `(?:$|[^\w\[\]\\^{}|` + "`" + `-])`
+)
+
+type highlighter struct {
+	nick     bool
+	ownNick  string
+	pattern  *regexp.Regexp
+	keywords []*regexp.Regexp
+	patterns []*regexp.Regexp
+	exclude  []string
+}
+
+type HighlightEvent struct {
+	Buffer   string
+	Position int
+	Log      utils.Log
+}
+
+func newHighlighter(cfg config.Highlight) (*highlighter, []error) {
+	h := &highlighter{
+		nick:    cfg.Nick,
+		exclude: cfg.Exclude,
+	}
+
+	errors := make([]error, 0)
+	for _, keyword := range cfg.Keywords {
+		h.keywords = append(h.keywords, wordPattern(keyword))
+	}
+	for _, expression := range cfg.Regexes {
+		pattern, err := regexp.Compile(expression)
+		if err != nil {
+			errors = append(errors, fmt.Errorf("invalid highlight regex %s: %w", expression, err))
+			continue
+		}
+		h.patterns = append(h.patterns, pattern)
+	}
+
+	return h, errors
+}
+
+func wordPattern(word string) *regexp.Regexp {
+	return regexp.MustCompile("(?i)" + nickStart + regexp.QuoteMeta(word) + nickEnd)
+}
+
+func (h *highlighter) setNick(nick string) {
+	if nick != h.ownNick {
+		h.ownNick = nick
+		h.pattern = wordPattern(nick)
+	}
+}
+
+func (h *highlighter) matches(buffer string, source string, text string) bool {
+	if source == h.ownNick {
+		return false
+	}
+	for _, excluded := range h.exclude {
+		if strings.EqualFold(excluded, buffer) || strings.EqualFold(excluded, source) {
+			return false
+		}
+	}
+
+	if h.nick && h.pattern != nil && h.pattern.MatchString(text) {
+		return true
+	}
+	for _, keyword := range h.keywords {
+		if keyword.MatchString(text) {
+			return true
+		}
+	}
+	for _, pattern := range h.patterns {
+		if pattern.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"ribbirc/config"
+	"testing"
+)
+
+func TestHighlighter(t *testing.T) {
+	h, _ := newHighlighter(config.Highlight{
+		Nick:     true,
+		Keywords: []string{"ribbit"},
+		Regexes:  []string{`(?i)\bfrogs?\b`},
+		Exclude:  []string{"#noisy", "bot"},
+	})
+	h.setNick("me")
+
+	tests := map[string]struct {
+		buffer string
+		source string
+		text   string
+		output bool
+	}{
+		"Nick":          {"#chan", "alice", "hey me, look", true},
+		"NickCase":      {"#chan", "alice", "ME: look", true},
+		"NickInWord":    {"#chan", "alice", "meme time", false},
+		"NickNickChars": {"#chan", "alice", "me_too and me|away", false},
+		"Keyword":       {"#chan", "alice", "Ribbit!", true},
+		"Regex":         {"#chan", "alice", "I like frogs", true},
+		"OwnMessage":    {"#chan", "me", "me me me", false},
+		"ExcludedChan":  {"#noisy", "alice", "me", false},
+		"ExcludedNick":  {"#chan", "bot", "me", false},
+		"Nothing":       {"#chan", "alice", "hello", false},
+	}
+
+	fails := 0
+	for testName, test := range tests {
+		t.Logf("Running test %s...", testName)
+
+		output := h.matches(test.buffer, test.source, test.text)
+		if output == test.output {
+			t.Logf("  PASS")
+		} else {
+			t.Logf("  FAIL: Expected %t, got %t", test.output, output)
+			fails++
+		}
+	}
+
+	if fails > 0 {
+		t.Fatalf("Failed %d/%d tests", fails, len(tests))
+	}
+}
@@ -0,0 +1,262 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"ribbirc/config"
+	"ribbirc/utils"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type IgnoreType int
+
+const (
+	IgnoreMessages IgnoreType = 1 << iota
+	IgnoreNotices
+	IgnoreCTCP
+	IgnoreJoins
+	IgnoreInvites
+
+	IgnoreAll = IgnoreMessages | IgnoreNotices | IgnoreCTCP | IgnoreJoins | IgnoreInvites
+)
+
+var ignoreTypeNames = map[string]IgnoreType{
+	"messages": IgnoreMessages,
+	"notices":  IgnoreNotices,
+	"ctcp":     IgnoreCTCP,
+	"joins":    IgnoreJoins,
+	"invites":  IgnoreInvites,
+	"all":      IgnoreAll,
+}
+
+type Ignore struct {
+	Mask    string   `json:"mask"`
+	Network string   `json:"network,omitempty"`
+	Channel string   `json:"channel,omitempty"`
+	Types   []string `json:"types,omitempty"`
+}
+
+func (i Ignore) types() IgnoreType {
+	if len(i.Types) == 0 {
+		return IgnoreAll
+	}
+	types := IgnoreType(0)
+	for _, name := range i.Types {
+		types |= ignoreTypeNames[name]
+	}
+	return types
+}
+
+func (i Ignore) String() string {
+	text := i.Mask
+	switch {
+	case i.Channel != "":
+		text += fmt.Sprintf(" on %s/%s", i.Network, i.Channel)
+	case i.Network != "":
+		text += fmt.Sprintf(" on %s", i.Network)
+	default:
+		text += " everywhere"
+	}
+	if len(i.Types) > 0 {
+		text += fmt.Sprintf(" (%s)", strings.Join(i.Types, ","))
+	}
+	return text
+}
+
+type ignoreList struct {
+	mutex   sync.Mutex
+	path    string
+	entries []Ignore
+}
+
+func loadIgnoreList(path string) (*ignoreList, error) {
+	l := &ignoreList{path: path, entries: make([]Ignore, 0)}
+	if path == "" {
+		return l, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return l, err
+	}
+	return l, json.Unmarshal(data, &l.entries)
+}
+
+func (l *ignoreList) save() error {
+	if l.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(l.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(l.path), 0o755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(l.path, data, 0o644)
+}
+
+func (l *ignoreList) list() []Ignore {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return append([]Ignore{}, l.entries...)
+}
+
+func (l *ignoreList) add(entry Ignore) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.entries = append(l.entries, entry)
+	return l.save()
+}
+
+func (l *ignoreList) remove(mask string) (bool, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	entries := make([]Ignore, 0, len(l.entries))
+	for i, entry := range l.entries {
+		if !strings.EqualFold(entry.Mask, mask) && strconv.Itoa(i+1) != mask {
+			entries = append(entries, entry)
+		}
+	}
+	if len(entries) == len(l.entries) {
+		return false, nil
+	}
+	l.entries = entries
+	return true, l.save()
+}
+
+func (l *ignoreList) matches(mapping string, network string, channel string, mask string, kind IgnoreType) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for _, entry := range l.entries {
+		if entry.types()&kind == 0 {
+			continue
+		}
+		if entry.Network != "" && !strings.EqualFold(entry.Network, network) {
+			continue
+		}
+		if entry.Channel != "" && utils.Casefold(mapping, entry.Channel) != utils.Casefold(mapping, channel) {
+			continue
+		}
+		if utils.MatchMask(mapping, entry.Mask, mask) {
+			return true
+		}
+	}
+	return false
+}
+
+func ignorePath() string {
+	dir, err := config.DataDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "ignores.json")
+}
+
+func (s *Server) ignored(message *utils.Message, channel string, kind IgnoreType) bool {
+	if message.SourceNick() == "" || message.SourceNick() == s.nick {
+		return false
+	}
+	mask := message.Source
+	if !strings.Contains(mask, "!") || !strings.Contains(mask, "@") {
+		mask = s.UserMask(message.SourceNick())
+	}
+	return s.ignores.matches(s.iSupport.casemapping, s.networkName(), channel, mask, kind)
+}
+
+func messageIgnoreType(message *utils.Message) IgnoreType {
+	if message.Command == "NOTICE" {
+		return IgnoreNotices
+	}
+	text := message.Parameters[len(message.Parameters)-1]
+	if strings.HasPrefix(text, "\x01") && !strings.HasPrefix(text, "\x01ACTION ") {
+		return IgnoreCTCP
+	}
+	return IgnoreMessages
+}
+
+func (s *Server) ignoreCommand(parts []string, buffer string) {
+	entry := Ignore{Network: s.networkName()}
+	args := make([]string, 0)
+	scoped := false
+	for _, part := range parts[1:] {
+		switch part {
+		case "-global":
+			entry.Network = ""
+		case "-channel":
+			entry.Channel, scoped = buffer, true
+		default:
+			args = append(args, part)
+		}
+	}
+
+	if len(args) == 0 {
+		entries := s.ignores.list()
+		if len(entries) == 0 {
+			s.log("Ignore list is empty")
+		}
+		for i, entry := range entries {
+			s.log(fmt.Sprintf("%d. %s", i+1, entry))
+		}
+		return
+	}
+	if len(args) > 2 || (scoped && (entry.Channel == "" || !strings.ContainsRune(s.iSupport.chantypes, rune(entry.Channel[0])))) {
+		s.invalidCommandParameters("/ignore [-global] [-channel] <mask> [<type>{,<type>}]")
+		return
+	}
+
+	entry.Mask = args[0]
+	if !strings.ContainsAny(entry.Mask, "!@") {
+		entry.Mask += "!*@*"
+	}
+	if len(args) > 1 {
+		entry.Types = strings.Split(strings.ToLower(args[1]), ",")
+		sort.Strings(entry.Types)
+		for _, name := range entry.Types {
+			if _, ok := ignoreTypeNames[name]; !ok {
+				s.logs.Append("System", utils.LogError, fmt.Sprintf("Unknown ignore type %s, expected one of messages, notices, ctcp, joins, invites, all", name))
+				return
+			}
+		}
+	}
+
+	if err := s.ignores.add(entry); err != nil {
+		s.logs.Append("System", utils.LogError, fmt.Sprintf("Could not save ignore list: %s", err))
+	}
+	s.log(fmt.Sprintf("Ignoring %s", entry))
+}
+
+func (s *Server) unignoreCommand(parts []string) {
+	if len(parts) != 2 {
+		s.invalidCommandParameters("/unignore <mask|number>")
+		return
+	}
+
+	mask := parts[1]
+	if _, err := strconv.Atoi(mask); err != nil && !strings.ContainsAny(mask, "!@") {
+		mask += "!*@*"
+	}
+	removed, err := s.ignores.remove(mask)
+	if err != nil {
+		s.logs.Append("System", utils.LogError, fmt.Sprintf("Could not save ignore list: %s", err))
+	}
+	if removed {
+		s.log(fmt.Sprintf("No longer ignoring %s", mask))
+	} else {
+		s.logs.Append("System", utils.LogError, fmt.Sprintf("%s is not ignored", mask))
+	}
+}
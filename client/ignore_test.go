@@ -0,0 +1,46 @@
+package client
+
+import (
+	"testing"
+)
+
+func TestIgnoreList(t *testing.T) {
+	ignores := &ignoreList{entries: []Ignore{
+		{Mask: "spam!*@*"},
+		{Mask: "*!*@bots.example", Network: "Libera.Chat", Types: []string{"joins"}},
+		{Mask: "loud!*@*", Network: "Libera.Chat", Channel: "#quiet", Types: []string{"messages", "notices"}},
+	}}
+
+	tests := map[string]struct {
+		network string
+		channel string
+		mask    string
+		kind    IgnoreType
+		output  bool
+	}{
+		"Global":       {"OFTC", "#a", "Spam!u@h", IgnoreCTCP, true},
+		"NetworkJoins": {"Libera.Chat", "#a", "b!u@bots.example", IgnoreJoins, true},
+		"NetworkType":  {"Libera.Chat", "#a", "b!u@bots.example", IgnoreMessages, false},
+		"OtherNetwork": {"OFTC", "#a", "b!u@bots.example", IgnoreJoins, false},
+		"Channel":      {"Libera.Chat", "#QUIET", "loud!u@h", IgnoreMessages, true},
+		"OtherChannel": {"Libera.Chat", "#other", "loud!u@h", IgnoreMessages, false},
+		"NotIgnored":   {"Libera.Chat", "#quiet", "friend!u@h", IgnoreMessages, false},
+	}
+
+	fails := 0
+	for testName, test := range tests {
+		t.Logf("Running test %s...", testName)
+
+		output := ignores.matches("rfc1459", test.network, test.channel, test.mask, test.kind)
+		if output == test.output {
+			t.Logf("  PASS")
+		} else {
+			t.Logf("  FAIL: Expected %t, got %t", test.output, output)
+			fails++
+		}
+	}
+
+	if fails > 0 {
+		t.Fatalf("Failed %d/%d tests", fails, len(tests))
+	}
+}
@@ -30,6 +30,7 @@ type ISupport struct {
 	targmax     string
 	topiclen    int
 	userlen     int
+	whox        bool
 }
 
 func newISupport() *ISupport {
@@ -148,9 +149,37 @@ func (i *ISupport) parseToken(token string) {
 		i.topiclen = 0
 	case "TOPICLEN":
 		i.topiclen, _ = strconv.Atoi(value)
+	case "-WHOX":
+		i.whox = false
+	case "WHOX":
+		i.whox = true
 	case "-USERLEN":
 		i.userlen = 0
 	case "USERLEN":
 		i.userlen, _ = strconv.Atoi(value)
 	}
 }
+
+func (i *ISupport) hasElist(condition rune) bool {
+	return strings.ContainsRune(strings.ToUpper(i.elist), condition)
+}
+
+func (i *ISupport) prefixModes() (string, string) {
+	prefix := i.prefix
+	if prefix == "" {
+		prefix = "(ov)@+"
+	}
+	end := strings.IndexByte(prefix, ')')
+	if prefix[0] != '(' || end < 0 {
+		return "", ""
+	}
+	return prefix[1:end], prefix[end+1:]
+}
+
+func (i *ISupport) chanModeTypes() []string {
+	types := strings.Split(i.chanmodes, ",")
+	for len(types) < 4 {
+		types = append(types, "")
+	}
+	return types
+}
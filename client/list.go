@@ -0,0 +1,150 @@
+package client
+
+import (
+	"fmt"
+	"path"
+	"ribbirc/utils"
+	"strconv"
+	"strings"
+)
+
+type ListEntry struct {
+	Channel string
+	Users   int
+	Topic   string
+}
+
+type ListResult struct {
+	Entries  []*ListEntry
+	TimedOut bool
+}
+
+type listCondition struct {
+	raw   string
+	elist rune
+	match func(entry *ListEntry) bool
+}
+
+func parseListCondition(raw string) (*listCondition, error) {
+	condition := &listCondition{raw: raw}
+
+	switch {
+	case raw[0] == '>' || raw[0] == '<':
+		count, err := strconv.Atoi(raw[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid user count in %s", raw)
+		}
+		condition.elist = 'U'
+		condition.match = func(entry *ListEntry) bool {
+			if raw[0] == '>' {
+				return entry.Users > count
+			}
+			return entry.Users < count
+		}
+	case len(raw) > 1 && (raw[0] == 'C' || raw[0] == 'T') && (raw[1] == '>' || raw[1] == '<'):
+		condition.elist = rune(raw[0])
+	case raw[0] == '!':
+		condition.elist = 'N'
+		condition.match = func(entry *ListEntry) bool {
+			matched, _ := path.Match(strings.ToLower(raw[1:]), strings.ToLower(entry.Channel))
+			return !matched
+		}
+	case strings.ContainsAny(raw, "*?"):
+		condition.elist = 'M'
+		condition.match = func(entry *ListEntry) bool {
+			matched, _ := path.Match(strings.ToLower(raw), strings.ToLower(entry.Channel))
+			return matched
+		}
+	default:
+		return nil, fmt.Errorf("unknown list condition %s", raw)
+	}
+
+	return condition, nil
+}
+
+func (s *Server) listCommand(args []string, buffer string) *utils.Message {
+	message := &utils.Message{Command: "LIST"}
+
+	channels := make([]string, 0)
+	sent := make([]string, 0)
+	local := make([]*listCondition, 0)
+	for _, arg := range args {
+		for _, token := range strings.Split(arg, ",") {
+			if token == "" {
+				continue
+			}
+			if strings.ContainsRune(s.iSupport.chantypes, rune(token[0])) && !strings.ContainsAny(token, "*?") {
+				channels = append(channels, token)
+				continue
+			}
+			condition, err := parseListCondition(token)
+			if err != nil {
+				s.logs.Append("System", utils.LogError, err.Error())
+				return nil
+			}
+			if s.iSupport.hasElist(condition.elist) {
+				sent = append(sent, condition.raw)
+			} else if condition.match != nil {
+				local = append(local, condition)
+			} else {
+				text := fmt.Sprintf("Server does not support list condition %s, ignoring it.", condition.raw)
+				s.logs.Append("System", utils.LogError, text)
+			}
+		}
+	}
+
+	if len(channels) > 0 {
+		message.Parameters = append(message.Parameters, strings.Join(channels, ","))
+	}
+	if len(sent) > 0 {
+		message.Parameters = append(message.Parameters, strings.Join(sent, ","))
+	}
+
+	s.expectReply(message, "list", "", func(reply *Reply) {
+		result := newListResult(reply)
+		result.filter(local)
+		s.notify(Event{Buffer: buffer, Data: result})
+	})
+
+	return message
+}
+
+func newListResult(reply *Reply) *ListResult {
+	result := &ListResult{
+		Entries:  make([]*ListEntry, 0),
+		TimedOut: reply.TimedOut,
+	}
+
+	for _, message := range reply.Messages {
+		// <client> <channel> <client count> :<topic>
+		if message.Command != utils.RPL_LIST || len(message.Parameters) < 4 {
+			continue
+		}
+		users, _ := strconv.Atoi(message.Parameters[2])
+		result.Entries = append(result.Entries, &ListEntry{
+			Channel: message.Parameters[1],
+			Users:   users,
+			Topic:   message.Parameters[3],
+		})
+	}
+
+	return result
+}
+
+func (r *ListResult) filter(conditions []*listCondition) {
+	if len(conditions) == 0 {
+		return
+	}
+
+	entries := make([]*ListEntry, 0)
+	for _, entry := range r.Entries {
+		keep := true
+		for _, condition := range conditions {
+			keep = keep && condition.match(entry)
+		}
+		if keep {
+			entries = append(entries, entry)
+		}
+	}
+	r.Entries = entries
+}
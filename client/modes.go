@@ -0,0 +1,43 @@
+package client
+
+import (
+	"fmt"
+	"ribbirc/utils"
+	"strings"
+)
+
+func (s *Server) handleChannelMode(channel *Channel, message *utils.Message) {
+	// <channel> <modestring> [<mode arguments>...]
+	modes, symbols := s.iSupport.prefixModes()
+	types := s.iSupport.chanModeTypes()
+	args := message.Parameters[2:]
+
+	add := true
+	for _, mode := range message.Parameters[1] {
+		switch {
+		case mode == '+' || mode == '-':
+			add = mode == '+'
+			continue
+		case strings.ContainsRune(modes, mode):
+			if len(args) == 0 {
+				continue
+			}
+			symbol := symbols[strings.IndexRune(modes, mode)]
+			channel.userMode(args[0], symbol, add)
+		case strings.ContainsRune(types[0], mode), strings.ContainsRune(types[1], mode):
+		case strings.ContainsRune(types[2], mode) && add:
+		default:
+			continue
+		}
+		if len(args) > 0 {
+			args = args[1:]
+		}
+	}
+
+	source := message.SourceNick()
+	if source == "" {
+		source = message.Source
+	}
+	text := fmt.Sprintf("%s sets mode %s", source, strings.Join(message.Parameters[1:], " "))
+	channel.Logs.Append("*", utils.LogSystem, text)
+}
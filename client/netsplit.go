@@ -0,0 +1,157 @@
+package client
+
+import (
+	"fmt"
+	"regexp"
+	"ribbirc/utils"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	netsplitDelay   = 2 * time.Second
+	netsplitExpiry  = 30 * time.Minute
+	netsplitMaxNick = 15
+)
+
+var netsplitPattern = regexp.MustCompile(`^([\w*-]+(?:\.[\w*-]+)+) ([\w*-]+(?:\.[\w*-]+)+)$`)
+
+type netsplit struct {
+	servers string
+	at      time.Time
+	nicks   map[string]bool
+	quits   *netsplitGroup
+	joins   *netsplitGroup
+}
+
+type netsplitGroup struct {
+	nicks    map[string]bool
+	channels map[*Channel][]string
+	order    []*Channel
+	timer    *time.Timer
+}
+
+type netsplits struct {
+	mutex  sync.Mutex
+	splits map[string]*netsplit
+}
+
+func newNetsplits() *netsplits {
+	return &netsplits{splits: make(map[string]*netsplit)}
+}
+
+func (g *netsplitGroup) add(channel *Channel, nick string) {
+	if _, ok := g.channels[channel]; !ok {
+		g.order = append(g.order, channel)
+	}
+	g.channels[channel] = append(g.channels[channel], nick)
+}
+
+func isNetsplit(reason string) (string, bool) {
+	match := netsplitPattern.FindStringSubmatch(reason)
+	if match == nil || match[1] == match[2] {
+		return "", false
+	}
+	return fmt.Sprintf("%s and %s", match[1], match[2]), true
+}
+
+func (s *Server) netsplit(message *utils.Message, reason string) bool {
+	servers, ok := isNetsplit(reason)
+	if !ok {
+		return false
+	}
+	nick := message.SourceNick()
+
+	s.netsplits.mutex.Lock()
+	for key, split := range s.netsplits.splits {
+		if time.Since(split.at) > netsplitExpiry {
+			delete(s.netsplits.splits, key)
+		}
+	}
+	split, ok := s.netsplits.splits[servers]
+	if !ok {
+		split = &netsplit{servers: servers, nicks: make(map[string]bool)}
+		s.netsplits.splits[servers] = split
+	}
+	split.at = time.Now()
+	split.nicks[s.users.key(nick)] = true
+	if split.quits == nil {
+		split.quits = s.groupNetsplit(split, "Netsplit", &split.quits)
+	}
+	for _, channel := range s.channels() {
+		if channel.hasMember(nick) && !s.ignored(message, channel.Name, IgnoreJoins) {
+			split.quits.add(channel, nick)
+		}
+	}
+	split.quits.timer.Reset(netsplitDelay)
+	s.netsplits.mutex.Unlock()
+
+	for _, channel := range s.channels() {
+		channel.userQuit(nick, reason, false)
+	}
+	return true
+}
+
+func (s *Server) netjoin(channel *Channel, nick string) bool {
+	s.netsplits.mutex.Lock()
+	defer s.netsplits.mutex.Unlock()
+
+	key := s.users.key(nick)
+	for servers, split := range s.netsplits.splits {
+		if split.joins == nil && time.Since(split.at) > netsplitExpiry {
+			delete(s.netsplits.splits, servers)
+			continue
+		}
+		if !split.nicks[key] && (split.joins == nil || !split.joins.nicks[key]) {
+			continue
+		}
+		if split.joins == nil {
+			split.joins = s.groupNetsplit(split, "Netjoin", &split.joins)
+		}
+		delete(split.nicks, key)
+		split.joins.nicks[key] = true
+		split.joins.add(channel, nick)
+		split.joins.timer.Reset(netsplitDelay)
+		return true
+	}
+	return false
+}
+
+func (s *Server) groupNetsplit(split *netsplit, title string, current **netsplitGroup) *netsplitGroup {
+	group := &netsplitGroup{nicks: make(map[string]bool), channels: make(map[*Channel][]string)}
+	group.timer = time.AfterFunc(netsplitDelay, func() {
+		s.netsplits.mutex.Lock()
+		if *current != group {
+			s.netsplits.mutex.Unlock()
+			return
+		}
+		*current = nil
+		if len(split.nicks) == 0 && s.netsplits.splits[split.servers] == split {
+			delete(s.netsplits.splits, split.servers)
+		}
+		s.netsplits.mutex.Unlock()
+
+		for _, channel := range group.order {
+			channel.Logs.Append("*", utils.LogSystem, netsplitText(title, split.servers, group.channels[channel]))
+		}
+		s.notify(Event{})
+	})
+	return group
+}
+
+func netsplitText(title string, servers string, nicks []string) string {
+	users := "users"
+	if len(nicks) == 1 {
+		users = "user"
+	}
+	shown := nicks
+	if len(shown) > netsplitMaxNick {
+		shown = shown[:netsplitMaxNick]
+	}
+	text := fmt.Sprintf("%s between %s, %d %s: %s", title, servers, len(nicks), users, strings.Join(shown, ", "))
+	if len(nicks) > len(shown) {
+		text += fmt.Sprintf(" (+%d more)", len(nicks)-len(shown))
+	}
+	return text
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsNetsplit(t *testing.T) {
+	tests := map[string]struct {
+		reason  string
+		servers string
+		output  bool
+	}{
+		"Hidden":     {"*.net *.split", "*.net and *.split", true},
+		"Servers":    {"hub.libera.chat leaf.libera.chat", "hub.libera.chat and leaf.libera.chat", true},
+		"SameServer": {"a.example.com a.example.com", "", false},
+		"Message":    {"Quit: going home", "", false},
+		"Words":      {"see you", "", false},
+		"Spoofed":    {"hub.libera.chat leaf.libera.chat extra", "", false},
+	}
+
+	fails := 0
+	for testName, test := range tests {
+		t.Logf("Running test %s...", testName)
+
+		servers, output := isNetsplit(test.reason)
+		if output == test.output && servers == test.servers {
+			t.Logf("  PASS")
+		} else {
+			t.Logf("  FAIL: Expected '%s' %t, got '%s' %t", test.servers, test.output, servers, output)
+			fails++
+		}
+	}
+
+	if fails > 0 {
+		t.Fatalf("Failed %d/%d tests", fails, len(tests))
+	}
+}
+
+func TestNetjoin(t *testing.T) {
+	type join struct {
+		channel string
+		nick    string
+		output  bool
+	}
+
+	tests := map[string]struct {
+		age       time.Duration
+		joins     []join
+		remaining int
+		dropped   bool
+	}{
+		"Rejoin": {
+			joins:     []join{{"#a", "alice", true}, {"#b", "Alice", true}},
+			remaining: 1,
+		},
+		"Stranger": {
+			joins:     []join{{"#a", "carol", false}},
+			remaining: 2,
+		},
+		"Expired": {
+			age:       netsplitExpiry + time.Minute,
+			joins:     []join{{"#a", "alice", false}},
+			remaining: 2,
+			dropped:   true,
+		},
+		"All": {
+			joins:     []join{{"#a", "alice", true}, {"#a", "bob", true}, {"#b", "bob", true}},
+			remaining: 0,
+		},
+	}
+
+	fails := 0
+	for testName, test := range tests {
+		t.Logf("Running test %s...", testName)
+
+		s := &Server{
+			listener:  make(chan Event, 16),
+			netsplits: newNetsplits(),
+			users:     newUserRegistry(func() string { return "ascii" }),
+		}
+		split := &netsplit{servers: "a and b", at: time.Now().Add(-test.age), nicks: map[string]bool{"alice": true, "bob": true}}
+		s.netsplits.splits[split.servers] = split
+		channels := map[string]*Channel{"#a": newChannel("#a", "@+", 10), "#b": newChannel("#b", "@+", 10)}
+
+		ok := true
+		for _, j := range test.joins {
+			if s.netjoin(channels[j.channel], j.nick) != j.output {
+				t.Logf("  FAIL: Expected %t for %s joining %s", j.output, j.nick, j.channel)
+				ok = false
+			}
+		}
+		if len(split.nicks) != test.remaining {
+			t.Logf("  FAIL: Expected %d nicks left in the split, got %d", test.remaining, len(split.nicks))
+			ok = false
+		}
+		if _, kept := s.netsplits.splits[split.servers]; kept == test.dropped {
+			t.Logf("  FAIL: Expected the split to be dropped: %t", test.dropped)
+			ok = false
+		}
+		if split.joins != nil {
+			split.joins.timer.Stop()
+		}
+		if ok {
+			t.Logf("  PASS")
+		} else {
+			fails++
+		}
+	}
+
+	if fails > 0 {
+		t.Fatalf("Failed %d/%d tests", fails, len(tests))
+	}
+}
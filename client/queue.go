@@ -0,0 +1,64 @@
+package client
+
+import (
+	"fmt"
+	"ribbirc/utils"
+	"time"
+)
+
+const (
+	sendQueueSize = 512
+	sendBurst     = 5
+	sendInterval  = 2 * time.Second
+)
+
+type sendLimiter struct {
+	until time.Time
+}
+
+func (l *sendLimiter) delay(now time.Time) time.Duration {
+	if l.until.Before(now) {
+		l.until = now
+	}
+	l.until = l.until.Add(sendInterval)
+	return max(0, l.until.Sub(now)-sendBurst*sendInterval)
+}
+
+func (s *Server) writeMessages() {
+	limiter := &sendLimiter{}
+	for {
+		select {
+		case message := <-s.outgoing:
+			if delay := limiter.delay(time.Now()); delay > 0 {
+				s.notify(Event{})
+				select {
+				case <-time.After(delay):
+				case <-s.closed:
+					return
+				}
+			}
+			s.writeMessage(message)
+		case <-s.closed:
+			return
+		}
+	}
+}
+
+func (s *Server) flushQueue() {
+	for {
+		select {
+		case message := <-s.outgoing:
+			s.writeMessage(message)
+		default:
+			return
+		}
+	}
+}
+
+func (s *Server) writeMessage(message *utils.Message) {
+	data := utils.MarshalMessage(message)
+	_, err := s.conn.Write([]byte(data + "\r\n"))
+	if err != nil && !s.quitting.Load() {
+		s.logs.Append("System", utils.LogError, fmt.Sprintf("Could not send %s: %s", message.Command, err))
+	}
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSendLimiter(t *testing.T) {
+	tests := map[string]struct {
+		sends  []time.Duration
+		delays []time.Duration
+	}{
+		"Burst": {
+			sends:  []time.Duration{0, 0, 0, 0, 0},
+			delays: []time.Duration{0, 0, 0, 0, 0},
+		},
+		"OverBurst": {
+			sends:  []time.Duration{0, 0, 0, 0, 0, 0, 0},
+			delays: []time.Duration{0, 0, 0, 0, 0, sendInterval, 2 * sendInterval},
+		},
+		"Recovered": {
+			sends:  []time.Duration{0, 0, 0, 0, 0, 0, 20 * time.Second},
+			delays: []time.Duration{0, 0, 0, 0, 0, sendInterval, 0},
+		},
+		"Spaced": {
+			sends:  []time.Duration{0, 3 * time.Second, 6 * time.Second},
+			delays: []time.Duration{0, 0, 0},
+		},
+	}
+
+	fails := 0
+	for testName, test := range tests {
+		t.Logf("Running test %s...", testName)
+
+		start := time.Now()
+		limiter := &sendLimiter{}
+		delays := make([]time.Duration, 0, len(test.sends))
+		for _, at := range test.sends {
+			delays = append(delays, limiter.delay(start.Add(at)))
+		}
+		if slices.Equal(delays, test.delays) {
+			t.Logf("  PASS")
+		} else {
+			t.Logf("  FAIL: Expected %v, got %v", test.delays, delays)
+			fails++
+		}
+	}
+
+	if fails > 0 {
+		t.Fatalf("Failed %d/%d tests", fails, len(tests))
+	}
+}
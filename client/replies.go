@@ -0,0 +1,355 @@
+package client
+
+import (
+	"fmt"
+	"ribbirc/utils"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const replyTimeout = 30 * time.Second
+
+type Reply struct {
+	Kind     string
+	Target   string
+	Messages []*utils.Message
+	Error    *utils.Message
+	TimedOut bool
+}
+
+type replyRole int
+
+const (
+	replyNone replyRole = iota
+	replyStart
+	replyMember
+	replyEnd
+	replyError
+)
+
+type replySpec struct {
+	kind     string
+	start    []string
+	members  []string
+	optional []string
+	end      []string
+	errors   []string
+	target   int
+}
+
+var replySpecs = []*replySpec{
+	{
+		kind:    "motd",
+		start:   []string{utils.RPL_MOTDSTART},
+		members: []string{utils.RPL_MOTD},
+		end:     []string{utils.RPL_ENDOFMOTD},
+		errors:  []string{utils.ERR_NOMOTD},
+	},
+	{
+		kind:    "info",
+		members: []string{utils.RPL_INFO},
+		end:     []string{utils.RPL_ENDOFINFO},
+	},
+	{
+		kind:    "links",
+		members: []string{utils.RPL_LINKS},
+		end:     []string{utils.RPL_ENDOFLINKS},
+	},
+	{
+		kind:    "help",
+		start:   []string{utils.RPL_HELPSTART},
+		members: []string{utils.RPL_HELPTXT},
+		end:     []string{utils.RPL_ENDOFHELP},
+		errors:  []string{utils.ERR_HELPNOTFOUND},
+	},
+	{
+		kind:    "list",
+		start:   []string{utils.RPL_LISTSTART},
+		members: []string{utils.RPL_LIST},
+		end:     []string{utils.RPL_LISTEND},
+	},
+	{
+		kind:    "stats",
+		members: []string{utils.RPL_STATSCOMMANDS, utils.RPL_STATSUPTIME},
+		end:     []string{utils.RPL_ENDOFSTATS},
+	},
+	{
+		kind:    "who",
+		members: []string{utils.RPL_WHOREPLY, utils.RPL_WHOSPCRPL},
+		end:     []string{utils.RPL_ENDOFWHO},
+	},
+	{
+		kind: "whois",
+		members: []string{
+			utils.RPL_WHOISCERTFP,
+			utils.RPL_WHOISREGNICK,
+			utils.RPL_WHOISUSER,
+			utils.RPL_WHOISSERVER,
+			utils.RPL_WHOISOPERATOR,
+			utils.RPL_WHOISIDLE,
+			utils.RPL_WHOISCHANNELS,
+			utils.RPL_WHOISSPECIAL,
+			utils.RPL_WHOISACCOUNT,
+			utils.RPL_WHOISACTUALLY,
+			utils.RPL_WHOISHOST,
+			utils.RPL_WHOISMODES,
+			utils.RPL_WHOISSECURE,
+		},
+		optional: []string{utils.RPL_AWAY},
+		end:      []string{utils.RPL_ENDOFWHOIS},
+		errors:   []string{utils.ERR_NOSUCHNICK},
+		target:   1,
+	},
+	{
+		kind: "whowas",
+		members: []string{
+			utils.RPL_WHOWASUSER,
+			utils.RPL_WHOISSERVER,
+			utils.RPL_WHOISACCOUNT,
+			utils.RPL_WHOISACTUALLY,
+		},
+		end:    []string{utils.RPL_ENDOFWHOWAS},
+		errors: []string{utils.ERR_WASNOSUCHNICK},
+		target: 1,
+	},
+}
+
+func (r *replySpec) role(command string) replyRole {
+	switch {
+	case contains(r.start, command):
+		return replyStart
+	case contains(r.members, command), contains(r.optional, command):
+		return replyMember
+	case contains(r.end, command):
+		return replyEnd
+	case contains(r.errors, command):
+		return replyError
+	}
+	return replyNone
+}
+
+func (r *replySpec) targetOf(message *utils.Message) string {
+	if r.target == 0 || r.target >= len(message.Parameters) {
+		return ""
+	}
+	return message.Parameters[r.target]
+}
+
+type pendingReply struct {
+	spec     *replySpec
+	label    string
+	reply    *Reply
+	timer    *time.Timer
+	callback func(*Reply)
+}
+
+type replyCollector struct {
+	mutex    sync.Mutex
+	nextID   int
+	timeout  time.Duration
+	pending  []*pendingReply
+	batches  map[string]string
+	fallback func(*Reply)
+	notify   func()
+}
+
+func newReplyCollector(timeout time.Duration, fallback func(*Reply), notify func()) *replyCollector {
+	return &replyCollector{
+		timeout:  timeout,
+		batches:  make(map[string]string),
+		fallback: fallback,
+		notify:   notify,
+	}
+}
+
+func (c *replyCollector) expect(kind string, target string, callback func(*Reply)) (string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var spec *replySpec
+	for _, s := range replySpecs {
+		if s.kind == kind {
+			spec = s
+		}
+	}
+	if spec == nil {
+		return "", fmt.Errorf("unknown reply kind %s", kind)
+	}
+	return c.add(spec, target, callback).label, nil
+}
+
+func (c *replyCollector) add(spec *replySpec, target string, callback func(*Reply)) *pendingReply {
+	c.nextID++
+	p := &pendingReply{
+		spec:     spec,
+		label:    strconv.Itoa(c.nextID),
+		reply:    &Reply{Kind: spec.kind, Target: target},
+		callback: callback,
+	}
+	p.timer = time.AfterFunc(c.timeout, func() {
+		c.expire(p)
+	})
+	c.pending = append(c.pending, p)
+	return p
+}
+
+func (c *replyCollector) expire(p *pendingReply) {
+	c.mutex.Lock()
+	if !c.remove(p) {
+		c.mutex.Unlock()
+		return
+	}
+	c.mutex.Unlock()
+
+	p.reply.TimedOut = true
+	p.callback(p.reply)
+	if c.notify != nil {
+		c.notify()
+	}
+}
+
+func (c *replyCollector) remove(p *pendingReply) bool {
+	for i, pending := range c.pending {
+		if pending == p {
+			c.pending = append(c.pending[:i], c.pending[i+1:]...)
+			p.timer.Stop()
+			return true
+		}
+	}
+	return false
+}
+
+func (c *replyCollector) handle(message *utils.Message) bool {
+	if message.Command == "BATCH" {
+		return c.handleBatch(message)
+	}
+
+	c.mutex.Lock()
+	p, role := c.match(message)
+	if p == nil {
+		c.mutex.Unlock()
+		return role == replyEnd
+	}
+
+	switch role {
+	case replyStart, replyMember:
+		p.reply.Messages = append(p.reply.Messages, message)
+		p.timer.Reset(c.timeout)
+		c.mutex.Unlock()
+		return true
+	case replyError:
+		p.reply.Error = message
+	}
+	c.remove(p)
+	c.mutex.Unlock()
+
+	p.callback(p.reply)
+	return role != replyError
+}
+
+func (c *replyCollector) match(message *utils.Message) (*pendingReply, replyRole) {
+	if label := c.labelOf(message); label != "" {
+		for _, p := range c.pending {
+			if p.label == label {
+				role := p.spec.role(message.Command)
+				if role == replyNone {
+					if _, ok := message.Tag("batch"); ok {
+						role = replyMember
+					} else if message.Command == "ACK" {
+						role = replyEnd
+					} else {
+						role = replyError
+					}
+				}
+				return p, role
+			}
+		}
+	}
+
+	for _, p := range c.pending {
+		role := p.spec.role(message.Command)
+		if role == replyNone {
+			continue
+		}
+		target := p.spec.targetOf(message)
+		if p.reply.Target == "" || target == "" || strings.EqualFold(p.reply.Target, target) {
+			return p, role
+		}
+	}
+
+	for _, spec := range replySpecs {
+		role := spec.role(message.Command)
+		if contains(spec.optional, message.Command) {
+			continue
+		}
+		if role == replyStart || role == replyMember {
+			return c.add(spec, spec.targetOf(message), c.fallback), role
+		}
+		if role != replyNone {
+			return nil, role
+		}
+	}
+	return nil, replyNone
+}
+
+func (c *replyCollector) labelOf(message *utils.Message) string {
+	if label, ok := message.Tag("label"); ok {
+		return label
+	}
+	if batch, ok := message.Tag("batch"); ok {
+		return c.batches[batch]
+	}
+	return ""
+}
+
+func (c *replyCollector) handleBatch(message *utils.Message) bool {
+	// BATCH {+,-}<reference tag> [<type> [<parameters>]]
+	if len(message.Parameters) == 0 || len(message.Parameters[0]) < 2 {
+		return true
+	}
+	reference := message.Parameters[0]
+	if reference[0] != '+' && reference[0] != '-' {
+		return true
+	}
+
+	c.mutex.Lock()
+	if reference[0] == '+' {
+		label, ok := message.Tag("label")
+		if ok && len(message.Parameters) > 1 && message.Parameters[1] == "labeled-response" {
+			c.batches[reference[1:]] = label
+		}
+		c.mutex.Unlock()
+		return true
+	}
+
+	label, ok := c.batches[reference[1:]]
+	delete(c.batches, reference[1:])
+	var done *pendingReply
+	if ok {
+		for _, p := range c.pending {
+			if p.label == label {
+				done = p
+			}
+		}
+	}
+	if done != nil {
+		c.remove(done)
+	}
+	c.mutex.Unlock()
+
+	if done != nil {
+		done.callback(done.reply)
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
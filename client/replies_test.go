@@ -0,0 +1,157 @@
+package client
+
+import (
+	"ribbirc/utils"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReplyCollector(t *testing.T) {
+	type request struct {
+		kind   string
+		target string
+	}
+
+	tests := map[string]struct {
+		requests []request
+		input    []string
+		pause    time.Duration
+		replies  map[string]int
+		timeouts int
+		errors   int
+	}{
+		"Unsolicited": {
+			input: []string{
+				":srv 375 me :- srv Message of the day -",
+				":srv 372 me :- hello",
+				":srv 376 me :End of /MOTD command.",
+			},
+			replies: map[string]int{"motd": 2},
+		},
+		"Interleaved": {
+			requests: []request{{"whois", "alice"}, {"list", ""}, {"whois", "bob"}},
+			input: []string{
+				":srv 311 me bob b host * :Bob",
+				":srv 321 me Channel :Users Name",
+				":srv 311 me alice a host * :Alice",
+				":srv 322 me #chan 3 :topic",
+				":srv 319 me alice :#chan",
+				":srv 318 me alice :End of /WHOIS list.",
+				":srv 323 me :End of /LIST",
+				":srv 318 me bob :End of /WHOIS list.",
+			},
+			replies: map[string]int{"whois/alice": 2, "list": 2, "whois/bob": 1},
+		},
+		"Error": {
+			requests: []request{{"whois", "nobody"}},
+			input: []string{
+				":srv 401 me someone :No such nick/channel",
+				":srv 401 me nobody :No such nick/channel",
+				":srv 318 me nobody :End of /WHOIS list.",
+			},
+			replies: map[string]int{"whois/nobody": 0},
+		},
+		"OtherTarget": {
+			requests: []request{{"whois", "alice"}},
+			input: []string{
+				":srv 301 me bob :Gone fishing",
+				":srv 311 me alice a host * :Alice",
+				":srv 318 me alice :End of /WHOIS list.",
+			},
+			replies: map[string]int{"whois/alice": 1},
+		},
+		"Slow": {
+			requests: []request{{"list", ""}},
+			input: []string{
+				":srv 321 me Channel :Users Name",
+				":srv 322 me #a 3 :topic",
+				":srv 322 me #b 4 :topic",
+				":srv 322 me #c 5 :topic",
+				":srv 323 me :End of /LIST",
+			},
+			pause:   30 * time.Millisecond,
+			replies: map[string]int{"list": 4},
+		},
+		"UnknownKind": {
+			requests: []request{{"nope", ""}},
+			replies:  map[string]int{},
+			errors:   1,
+		},
+		"MalformedBatch": {
+			input: []string{
+				":srv BATCH",
+				":srv BATCH +",
+				":srv BATCH x",
+				":srv BATCH -unknown",
+			},
+			replies: map[string]int{},
+		},
+		"Labeled": {
+			requests: []request{{"who", "#a"}, {"who", "#b"}},
+			input: []string{
+				"@label=2 :srv BATCH +x labeled-response",
+				"@batch=x :srv 352 me #b u h srv carol H :0 Carol",
+				"@batch=x :srv 315 me #b :End of WHO list",
+				":srv BATCH -x",
+			},
+			replies:  map[string]int{"who/#b": 1},
+			timeouts: 1,
+		},
+	}
+
+	fails := 0
+	for testName, test := range tests {
+		t.Logf("Running test %s...", testName)
+
+		var mutex sync.Mutex
+		replies := make(map[string]int)
+		timeouts := 0
+		collect := func(reply *Reply) {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if reply.TimedOut {
+				timeouts++
+				return
+			}
+			key := reply.Kind
+			if reply.Target != "" {
+				key += "/" + reply.Target
+			}
+			replies[key] = len(reply.Messages)
+		}
+
+		collector := newReplyCollector(50*time.Millisecond, collect, nil)
+		errors := 0
+		for _, r := range test.requests {
+			if _, err := collector.expect(r.kind, r.target, collect); err != nil {
+				errors++
+			}
+		}
+		for _, line := range test.input {
+			collector.handle(utils.UnmarshalMessage(line))
+			time.Sleep(test.pause)
+		}
+		time.Sleep(100 * time.Millisecond)
+
+		mutex.Lock()
+		ok := len(replies) == len(test.replies) && timeouts == test.timeouts && errors == test.errors
+		for key, count := range test.replies {
+			if replies[key] != count {
+				ok = false
+			}
+		}
+		mutex.Unlock()
+		if ok {
+			t.Logf("  PASS")
+		} else {
+			t.Logf("  FAIL: Expected %v (%d timeouts), got %v (%d timeouts)", test.replies, test.timeouts, replies, timeouts)
+			fails++
+		}
+	}
+
+	if fails > 0 {
+		t.Fatalf("Failed %d/%d tests", fails, len(tests))
+	}
+}
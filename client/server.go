@@ -4,13 +4,17 @@ import (
 	"bufio"
 	"crypto/tls"
 	"fmt"
-	"log"
+	"ribbirc/config"
 	"ribbirc/utils"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type Server struct {
+	config   *config.Config
 	host     string
 	port     int
 	nick     string
@@ -22,23 +26,35 @@ type Server struct {
 	availableServerModes  string
 	availableChannelModes string
 	iSupport              *ISupport
+	caps                  *capabilities
 
 	conn           *tls.Conn
+	closed         chan struct{}
+	quitting       atomic.Bool
 	logs           *utils.Logger
-	listener       chan int
+	listener       chan Event
+	mutex          sync.Mutex
 	channelsJoined map[string]*Channel
+	replies        *replyCollector
+	users          *userRegistry
+	highlighter    *highlighter
+	ignores        *ignoreList
+	netsplits      *netsplits
 
-	bufferMotd  []string
-	bufferHelp  []string
-	bufferList  []string
-	bufferLinks []string
-	bufferInfo  []string
-	BufferWho   []string
-	BufferStats []string
+	away        bool
+	awayMessage string
+	active      string
+
+	state     ConnectionState
+	userModes string
+	lag       time.Duration
+	lagSent   time.Time
+	outgoing  chan *utils.Message
 }
 
-func New(listener chan int, host string, port int, nick string) *Server {
-	return &Server{
+func New(listener chan Event, cfg *config.Config, host string, port int, nick string) *Server {
+	s := &Server{
+		config:   cfg,
 		host:     host,
 		port:     port,
 		nick:     nick,
@@ -46,19 +62,34 @@ func New(listener chan int, host string, port int, nick string) *Server {
 		realName: nick,
 
 		iSupport: newISupport(),
+		caps:     newCapabilities(),
 
-		logs:           utils.NewLogger(),
+		closed:         make(chan struct{}),
+		outgoing:       make(chan *utils.Message, sendQueueSize),
+		logs:           utils.NewLogger(cfg.Scrollback.Status),
 		listener:       listener,
 		channelsJoined: make(map[string]*Channel),
-
-		bufferMotd:  make([]string, 0),
-		bufferHelp:  make([]string, 0),
-		bufferList:  make([]string, 0),
-		bufferLinks: make([]string, 0),
-		bufferInfo:  make([]string, 0),
-		BufferWho:   make([]string, 0),
-		BufferStats: make([]string, 0),
+		netsplits:      newNetsplits(),
+	}
+	s.logs.SetArchive(s.archive("server"), s.archiveFailed("server"))
+	var err error
+	var errors []error
+	s.highlighter, errors = newHighlighter(cfg.Highlight)
+	s.highlighter.setNick(nick)
+	for _, err := range errors {
+		s.logs.Append("System", utils.LogError, err.Error())
 	}
+	s.ignores, err = loadIgnoreList(ignorePath())
+	if err != nil {
+		s.logs.Append("System", utils.LogError, fmt.Sprintf("Could not load ignore list: %s", err))
+	}
+	s.users = newUserRegistry(func() string {
+		return s.iSupport.casemapping
+	})
+	s.replies = newReplyCollector(replyTimeout, s.logReply, func() {
+		s.notify(Event{})
+	})
+	return s
 }
 
 func (s *Server) GetLogger() *utils.Logger {
@@ -69,15 +100,21 @@ func (s *Server) Connect() (err error) {
 	address := fmt.Sprintf("%s:%d", s.host, s.port)
 	s.logs.Append("System", utils.LogStatus, fmt.Sprintf("Dialing %s...", address))
 
+	s.setState(StateConnecting)
 	s.conn, err = tls.Dial("tcp", address, nil)
 	if err != nil {
+		s.setState(StateDisconnected)
 		return err
 	}
+	s.setState(StateRegistering)
 
 	s.logs.Append("System", utils.LogStatus, fmt.Sprintf("Connected to %s", address))
 
+	s.caps.negotiating = true
 	go s.listenToMessages()
+	go s.writeMessages()
 
+	s.SendMessage(&utils.Message{Command: "CAP", Parameters: []string{"LS", "302"}})
 	s.SendMessage(&utils.Message{Command: "NICK", Parameters: []string{s.nick}})
 	s.SendMessage(&utils.Message{Command: "USER", Parameters: []string{s.username, "0", "*", s.realName}})
 
@@ -86,7 +123,7 @@ func (s *Server) Connect() (err error) {
 
 func (s *Server) ChannelNames() []string {
 	names := make([]string, 0)
-	for _, channel := range s.channelsJoined {
+	for _, channel := range s.channels() {
 		names = append(names, channel.Name)
 	}
 	sort.Strings(names)
@@ -94,19 +131,162 @@ func (s *Server) ChannelNames() []string {
 }
 
 func (s *Server) GetChannel(name string) (*Channel, error) {
-	if channel, ok := s.channelsJoined[name]; ok {
+	if channel := s.channel(name); channel != nil {
 		return channel, nil
 	}
 	return nil, fmt.Errorf("channel %s not found", name)
 }
 
+func (s *Server) channel(name string) *Channel {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.channelsJoined[name]
+}
+
+func (s *Server) channels() []*Channel {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	channels := make([]*Channel, 0, len(s.channelsJoined))
+	for _, channel := range s.channelsJoined {
+		channels = append(channels, channel)
+	}
+	return channels
+}
+
+func (s *Server) addChannel(name string) *Channel {
+	_, symbols := s.iSupport.prefixModes()
+	channel := newChannel(name, symbols, s.config.Scrollback.Channel)
+	channel.smartWindow = time.Duration(s.config.Filter.SmartMinutes) * time.Minute
+	channel.self = func() string {
+		return s.nick
+	}
+	s.attachArchive(channel.Logs, name)
+	s.trackActivity(channel)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.channelsJoined[name] = channel
+	return channel
+}
+
+func (s *Server) query(nick string) *Channel {
+	s.mutex.Lock()
+	query, ok := s.channelsJoined[nick]
+	if !ok {
+		query = newQuery(nick, s.config.Scrollback.Query)
+		s.attachArchive(query.Logs, nick)
+		s.trackActivity(query)
+		s.channelsJoined[nick] = query
+	}
+	s.mutex.Unlock()
+	return query
+}
+
+func (s *Server) OpenQuery(nick string) string {
+	return s.query(nick).Name
+}
+
+func (s *Server) renameQuery(oldNick string, newNick string) {
+	s.mutex.Lock()
+	query, ok := s.channelsJoined[oldNick]
+	if !ok || !query.Query {
+		s.mutex.Unlock()
+		return
+	}
+	delete(s.channelsJoined, oldNick)
+	query.Name = newNick
+	s.channelsJoined[newNick] = query
+	s.mutex.Unlock()
+
+	text := fmt.Sprintf("%s changed their nick to %s.", oldNick, newNick)
+	query.Logs.Append(oldNick, utils.LogNick, text)
+}
+
+func (s *Server) Away() (bool, string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.away, s.awayMessage
+}
+
+func (s *Server) SetAway(message string) {
+	s.mutex.Lock()
+	s.awayMessage = message
+	s.mutex.Unlock()
+
+	awayMessage := &utils.Message{Command: "AWAY"}
+	if message != "" {
+		awayMessage.Parameters = []string{message}
+	}
+	s.SendMessage(awayMessage)
+}
+
+func (s *Server) setAway(away bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.away = away
+	if !away {
+		s.awayMessage = ""
+	}
+}
+
+func (s *Server) removeChannel(name string) {
+	s.mutex.Lock()
+	channel, ok := s.channelsJoined[name]
+	delete(s.channelsJoined, name)
+	s.mutex.Unlock()
+
+	if ok {
+		for _, member := range channel.Members() {
+			s.forgetUser(member.Nick)
+		}
+		channel.Logs.Close()
+	}
+}
+
+func (s *Server) CloseBuffer(name string) error {
+	channel := s.channel(name)
+	if channel == nil {
+		return fmt.Errorf("buffer %s not found", name)
+	}
+	if !channel.Query {
+		s.SendMessage(&utils.Message{Command: "PART", Parameters: []string{name}})
+	}
+	s.removeChannel(name)
+	return nil
+}
+
 func (s *Server) SendMessage(message *utils.Message) {
-	data := utils.MarshalMessage(message)
+	select {
+	case s.outgoing <- message:
+	default:
+		s.logs.Append("System", utils.LogError, fmt.Sprintf("Could not send %s: send queue is full", message.Command))
+	}
+}
 
-	_, err := s.conn.Write([]byte(data + "\r\n"))
-	if err != nil {
-		log.Fatalf(err.Error())
+func (s *Server) Quit(message string, timeout time.Duration) {
+	if !s.quitting.Swap(true) {
+		s.flushQueue()
+		s.writeMessage(&utils.Message{Command: "QUIT", Parameters: []string{message}})
+	}
+
+	select {
+	case <-s.closed:
+	case <-time.After(timeout):
+	}
+	s.conn.Close()
+	s.Close()
+}
+
+func (s *Server) Close() {
+	for _, channel := range s.channels() {
+		channel.Logs.Close()
 	}
+	s.logs.Close()
 }
 
 func (s *Server) HandleUserInput(input string, channel string) {
@@ -120,7 +300,9 @@ func (s *Server) HandleUserInput(input string, channel string) {
 		if channel != "" {
 			message.Command = "PRIVMSG"
 			message.Parameters = []string{channel, input}
-			s.channelsJoined[channel].Logs.Append(s.nick, utils.LogPrivMsg, input)
+			if target := s.channel(channel); target != nil {
+				target.Logs.Append(s.nick, utils.LogPrivMsg, input)
+			}
 		} else {
 			s.SendMessage(utils.UnmarshalMessage(string(input)))
 			return
@@ -131,17 +313,100 @@ func (s *Server) HandleUserInput(input string, channel string) {
 }
 
 func (s *Server) listenToMessages() {
+	defer close(s.closed)
+	defer s.setState(StateDisconnected)
 	defer s.conn.Close()
 	reader := bufio.NewReader(s.conn)
 	for {
 		data, err := reader.ReadString('\n')
 		if err != nil {
-			log.Fatalf(err.Error())
+			if !s.quitting.Load() {
+				s.logs.Append("System", utils.LogError, fmt.Sprintf("Disconnected: %s", err))
+				s.notify(Event{})
+			}
+			return
 		}
 		data = strings.TrimRight(data, "\r\n")
 
 		s.handleServerMessage(utils.UnmarshalMessage(data))
-		s.listener <- 1
+		s.notify(Event{})
+	}
+}
+
+func (s *Server) expectReply(message *utils.Message, kind string, target string, callback func(*Reply)) {
+	label, err := s.replies.expect(kind, target, callback)
+	if err != nil {
+		s.logs.Append("System", utils.LogError, err.Error())
+		return
+	}
+	if s.caps.has("labeled-response") {
+		message.SetTag("label", label)
+	}
+}
+
+func (s *Server) logReply(reply *Reply) {
+	for _, message := range reply.Messages {
+		text := strings.Join(message.Parameters[1:], " ")
+		switch reply.Kind {
+		case "motd", "info", "help":
+			text = message.Parameters[len(message.Parameters)-1]
+		}
+		s.log(fmt.Sprintf("[%s] %s", reply.Kind, text))
+	}
+	if reply.TimedOut {
+		text := fmt.Sprintf("Timed out waiting for the %s reply", strings.ToUpper(reply.Kind))
+		s.logs.Append(s.host, utils.LogError, text)
+	}
+}
+
+func (s *Server) userMessage(channel *Channel, message *utils.Message) {
+	nick, text := message.SourceNick(), message.Parameters[1]
+	highlight := s.highlighter.matches(channel.Name, nick, text)
+	log, position := channel.userMessage(nick, text, highlight)
+	if highlight {
+		s.notify(Event{Buffer: channel.Name, Data: &HighlightEvent{Buffer: channel.Name, Position: position, Log: log}})
+	} else if channel.Query {
+		s.notify(Event{Buffer: channel.Name, Data: &MessageEvent{Buffer: channel.Name, Log: log}})
+	}
+}
+
+func (s *Server) networkName() string {
+	if s.iSupport.network != "" {
+		return s.iSupport.network
+	}
+	return s.host
+}
+
+func (s *Server) archive(buffer string) *utils.Archive {
+	if !s.config.Logs.Enabled {
+		return nil
+	}
+	root, err := s.config.LogsDir()
+	if err != nil {
+		return nil
+	}
+	return utils.NewArchive(root, s.networkName, buffer, s.config.Logs.Joins)
+}
+
+func (s *Server) attachArchive(logs *utils.Logger, buffer string) {
+	archive := s.archive(buffer)
+	if archive == nil {
+		return
+	}
+
+	if s.config.Logs.Restore > 0 {
+		history, err := archive.Tail(s.config.Logs.Restore)
+		if err != nil {
+			s.logs.Append("System", utils.LogError, fmt.Sprintf("Could not restore history for %s: %s", buffer, err))
+		}
+		logs.Restore(history)
+	}
+	logs.SetArchive(archive, s.archiveFailed(buffer))
+}
+
+func (s *Server) archiveFailed(buffer string) func(error) {
+	return func(err error) {
+		s.logs.Append("System", utils.LogError, fmt.Sprintf("Could not write log file for %s: %s", buffer, err))
 	}
 }
 
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"fmt"
+	"ribbirc/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	lagInterval = 30 * time.Second
+	lagToken    = "lag-"
+)
+
+type ConnectionState int
+
+const (
+	StateDisconnected ConnectionState = iota
+	StateConnecting
+	StateRegistering
+	StateConnected
+)
+
+func (c ConnectionState) String() string {
+	switch c {
+	case StateConnecting:
+		return "connecting"
+	case StateRegistering:
+		return "registering"
+	case StateConnected:
+		return "connected"
+	}
+	return "disconnected"
+}
+
+type Status struct {
+	Nick    string
+	Modes   string
+	Network string
+	Lag     time.Duration
+	State   ConnectionState
+	Queue   int
+}
+
+func (s *Server) Status() Status {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	lag := s.lag
+	if !s.lagSent.IsZero() {
+		lag = max(lag, time.Since(s.lagSent))
+	}
+	return Status{
+		Nick:    s.nick,
+		Modes:   s.userModes,
+		Network: s.networkName(),
+		Lag:     lag,
+		State:   s.state,
+		Queue:   len(s.outgoing),
+	}
+}
+
+func (s *Server) setState(state ConnectionState) {
+	s.mutex.Lock()
+	s.state = state
+	s.mutex.Unlock()
+}
+
+func (s *Server) setNick(nick string) {
+	s.mutex.Lock()
+	s.nick = nick
+	s.mutex.Unlock()
+	s.highlighter.setNick(nick)
+}
+
+func (s *Server) setUserModes(modes string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.userModes = applyUserModes(s.userModes, modes)
+}
+
+func applyUserModes(current string, changes string) string {
+	add := true
+	for _, mode := range changes {
+		switch {
+		case mode == '+' || mode == '-':
+			add = mode == '+'
+		case add && !strings.ContainsRune(current, mode):
+			current += string(mode)
+		case !add:
+			current = strings.ReplaceAll(current, string(mode), "")
+		}
+	}
+	return current
+}
+
+func (s *Server) measureLag() {
+	ticker := time.NewTicker(lagInterval)
+	defer ticker.Stop()
+
+	for {
+		s.mutex.Lock()
+		pending := !s.lagSent.IsZero()
+		if !pending {
+			s.lagSent = time.Now()
+		}
+		sent := s.lagSent
+		s.mutex.Unlock()
+
+		if !pending {
+			token := lagToken + strconv.FormatInt(sent.UnixMilli(), 10)
+			s.writeMessage(&utils.Message{Command: "PING", Parameters: []string{token}})
+		}
+
+		select {
+		case <-ticker.C:
+			s.notify(Event{})
+		case <-s.closed:
+			return
+		}
+	}
+}
+
+func (s *Server) handlePong(message *utils.Message) {
+	token := message.Parameters[len(message.Parameters)-1]
+	if strings.HasPrefix(token, lagToken) {
+		s.mutex.Lock()
+		if !s.lagSent.IsZero() {
+			s.lag = time.Since(s.lagSent)
+			s.lagSent = time.Time{}
+		}
+		s.mutex.Unlock()
+		return
+	}
+
+	then, err := strconv.ParseInt(token, 10, 64)
+	if err != nil {
+		return
+	}
+	diff := time.Now().UnixMilli() - then
+	s.log(fmt.Sprintf("Pong received, response time %dms", diff))
+}
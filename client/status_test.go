@@ -0,0 +1,56 @@
+package client
+
+import (
+	"testing"
+)
+
+func TestApplyUserModes(t *testing.T) {
+	tests := map[string]struct {
+		current string
+		changes string
+		output  string
+	}{
+		"Add": {
+			current: "",
+			changes: "+iw",
+			output:  "iw",
+		},
+		"AddExisting": {
+			current: "iw",
+			changes: "+ix",
+			output:  "iwx",
+		},
+		"Remove": {
+			current: "iwx",
+			changes: "-w",
+			output:  "ix",
+		},
+		"Mixed": {
+			current: "i",
+			changes: "+Z-i+w",
+			output:  "Zw",
+		},
+		"RemoveMissing": {
+			current: "i",
+			changes: "-o",
+			output:  "i",
+		},
+	}
+
+	fails := 0
+	for testName, test := range tests {
+		t.Logf("Running test %s...", testName)
+
+		output := applyUserModes(test.current, test.changes)
+		if output == test.output {
+			t.Logf("  PASS")
+		} else {
+			t.Logf("  FAIL: Expected '%s', got '%s'", test.output, output)
+			fails++
+		}
+	}
+
+	if fails > 0 {
+		t.Fatalf("Failed %d/%d tests", fails, len(tests))
+	}
+}
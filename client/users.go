@@ -0,0 +1,258 @@
+package client
+
+import (
+	"fmt"
+	"ribbirc/utils"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const whoxToken = "142"
+
+type User struct {
+	Nick        string
+	User        string
+	Host        string
+	Account     string
+	RealName    string
+	Away        bool
+	AwayMessage string
+}
+
+func (u User) Mask() string {
+	user, host := u.User, u.Host
+	if user == "" {
+		user = "*"
+	}
+	if host == "" {
+		host = "*"
+	}
+	return fmt.Sprintf("%s!%s@%s", u.Nick, user, host)
+}
+
+type userRegistry struct {
+	mutex       sync.Mutex
+	users       map[string]*User
+	casemapping func() string
+}
+
+func newUserRegistry(casemapping func() string) *userRegistry {
+	return &userRegistry{
+		users:       make(map[string]*User),
+		casemapping: casemapping,
+	}
+}
+
+func (r *userRegistry) key(nick string) string {
+	return utils.Casefold(r.casemapping(), nick)
+}
+
+func (r *userRegistry) get(nick string) (User, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if user, ok := r.users[r.key(nick)]; ok {
+		return *user, true
+	}
+	return User{}, false
+}
+
+func (r *userRegistry) update(nick string, create bool, fn func(user *User)) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	user, ok := r.users[r.key(nick)]
+	if !ok {
+		if !create {
+			return
+		}
+		user = &User{Nick: nick}
+		r.users[r.key(nick)] = user
+	}
+	fn(user)
+}
+
+func (r *userRegistry) see(mask string, create bool) {
+	nick, userHost, found := strings.Cut(mask, "!")
+	if nick == "" {
+		return
+	}
+	r.update(nick, create, func(user *User) {
+		user.Nick = nick
+		if found {
+			user.User, user.Host, _ = strings.Cut(userHost, "@")
+		}
+	})
+}
+
+func (r *userRegistry) rename(oldNick string, newNick string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	user, ok := r.users[r.key(oldNick)]
+	if !ok {
+		return
+	}
+	delete(r.users, r.key(oldNick))
+	user.Nick = newNick
+	r.users[r.key(newNick)] = user
+}
+
+func (r *userRegistry) remove(nick string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.users, r.key(nick))
+}
+
+func (s *Server) User(nick string) (User, bool) {
+	return s.users.get(nick)
+}
+
+func (s *Server) UserMask(nick string) string {
+	if user, ok := s.users.get(nick); ok {
+		return user.Mask()
+	}
+	return User{Nick: nick}.Mask()
+}
+
+func (s *Server) FoldNick(nick string) string {
+	return s.users.key(nick)
+}
+
+func (s *Server) CompleteNick(buffer string, prefix string) []string {
+	channel, err := s.GetChannel(buffer)
+	if err != nil {
+		return nil
+	}
+
+	mapping := s.iSupport.casemapping
+	folded := utils.Casefold(mapping, prefix)
+	nicks := make([]string, 0)
+	for _, member := range channel.Members() {
+		if member.Nick != s.nick && strings.HasPrefix(utils.Casefold(mapping, member.Nick), folded) {
+			nicks = append(nicks, member.Nick)
+		}
+	}
+	sort.Slice(nicks, func(i, j int) bool {
+		return utils.Casefold(mapping, nicks[i]) < utils.Casefold(mapping, nicks[j])
+	})
+	return nicks
+}
+
+func (s *Server) trackUser(message *utils.Message) {
+	nick := message.SourceNick()
+	if nick != "" {
+		s.users.see(message.Source, message.Command == "JOIN")
+	}
+
+	switch message.Command {
+	case "JOIN":
+		// <channel> [<account> :<realname>]
+		if len(message.Parameters) > 2 {
+			s.users.update(nick, false, func(user *User) {
+				user.Account = accountName(message.Parameters[1], "*")
+				user.RealName = message.Parameters[2]
+			})
+		}
+
+	case "ACCOUNT":
+		// <accountname>
+		s.users.update(nick, false, func(user *User) {
+			user.Account = accountName(message.Parameters[0], "*")
+		})
+
+	case "AWAY":
+		// [:<message>]
+		s.users.update(nick, false, func(user *User) {
+			user.Away = len(message.Parameters) > 0
+			user.AwayMessage = ""
+			if user.Away {
+				user.AwayMessage = message.Parameters[0]
+			}
+		})
+
+	case "CHGHOST":
+		// <new_user> <new_host>
+		s.users.update(nick, false, func(user *User) {
+			user.User = message.Parameters[0]
+			user.Host = message.Parameters[1]
+		})
+
+	case "NICK":
+		s.users.rename(nick, message.Parameters[0])
+
+	case utils.RPL_WHOREPLY:
+		// <client> <channel> <username> <host> <server> <nick> <flags> :<hopcount> <realname>
+		params := message.Parameters
+		if len(params) < 8 {
+			return
+		}
+		_, realName, _ := strings.Cut(params[7], " ")
+		s.users.update(params[5], false, func(user *User) {
+			user.User = params[2]
+			user.Host = params[3]
+			user.RealName = realName
+			user.Away = strings.HasPrefix(params[6], "G")
+		})
+
+	case utils.RPL_WHOSPCRPL:
+		// <client> <token> <channel> <username> <host> <nick> <flags> <account> :<realname>
+		params := message.Parameters
+		if len(params) < 9 || params[1] != whoxToken {
+			return
+		}
+		s.users.update(params[5], false, func(user *User) {
+			user.User = params[3]
+			user.Host = params[4]
+			user.Away = strings.HasPrefix(params[6], "G")
+			user.Account = accountName(params[7], "0")
+			user.RealName = params[8]
+		})
+	}
+}
+
+func accountName(account string, none string) string {
+	if account == none {
+		return ""
+	}
+	return account
+}
+
+func (s *Server) forgetUser(nick string) {
+	if nick == s.nick {
+		return
+	}
+	for _, channel := range s.channels() {
+		if channel.hasMember(nick) {
+			return
+		}
+	}
+	s.users.remove(nick)
+}
+
+func (s *Server) parseNames(names string) []Member {
+	_, symbols := s.iSupport.prefixModes()
+
+	members := make([]Member, 0)
+	for _, entry := range strings.Fields(names) {
+		mask := strings.TrimLeft(entry, symbols)
+		if mask == "" {
+			continue
+		}
+		s.users.see(mask, true)
+		nick, _, _ := strings.Cut(mask, "!")
+		members = append(members, Member{nick, entry[:len(entry)-len(mask)]})
+	}
+	return members
+}
+
+func (s *Server) requestChannelUsers(channel string) {
+	message := &utils.Message{Command: "WHO", Parameters: []string{channel}}
+	if s.iSupport.whox {
+		message.Parameters = append(message.Parameters, "%tcuhnfar,"+whoxToken)
+	}
+	s.expectReply(message, "who", channel, func(reply *Reply) {})
+	s.SendMessage(message)
+}
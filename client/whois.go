@@ -0,0 +1,153 @@
+package client
+
+import (
+	"ribbirc/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type WhoisInfo struct {
+	Nick       string
+	User       string
+	Host       string
+	RealName   string
+	Server     string
+	ServerInfo string
+	Account    string
+	Registered bool
+	Channels   []string
+	Idle       time.Duration
+	SignOn     time.Time
+	Secure     bool
+	CertFP     string
+	Operator   bool
+	Away       string
+	Actually   string
+	Modes      string
+	Extra      []string
+
+	NotFound bool
+	TimedOut bool
+}
+
+type WhoEntry struct {
+	Channel  string
+	User     string
+	Host     string
+	Server   string
+	Nick     string
+	Flags    string
+	Hops     int
+	RealName string
+}
+
+type WhoResult struct {
+	Mask     string
+	Entries  []*WhoEntry
+	TimedOut bool
+}
+
+func newWhoisInfo(reply *Reply) *WhoisInfo {
+	info := &WhoisInfo{
+		Nick:     reply.Target,
+		NotFound: reply.Error != nil,
+		TimedOut: reply.TimedOut,
+	}
+
+	for _, message := range reply.Messages {
+		params := message.Parameters
+		last := params[len(params)-1]
+		switch message.Command {
+		case utils.RPL_WHOISUSER:
+			// <client> <nick> <username> <host> * :<realname>
+			info.Nick = params[1]
+			info.User = params[2]
+			info.Host = params[3]
+			info.RealName = last
+		case utils.RPL_WHOISSERVER:
+			// <client> <nick> <server> :<server info>
+			info.Server = params[2]
+			info.ServerInfo = last
+		case utils.RPL_WHOISOPERATOR:
+			// <client> <nick> :is an IRC operator
+			info.Operator = true
+		case utils.RPL_WHOISIDLE:
+			// <client> <nick> <secs> <signon> :seconds idle, signon time
+			seconds, _ := strconv.Atoi(params[2])
+			info.Idle = time.Duration(seconds) * time.Second
+			if len(params) > 4 {
+				signOn, _ := strconv.ParseInt(params[3], 10, 64)
+				info.SignOn = time.Unix(signOn, 0)
+			}
+		case utils.RPL_WHOISCHANNELS:
+			// <client> <nick> :[prefix]<channel>{ [prefix]<channel>}
+			info.Channels = append(info.Channels, strings.Fields(last)...)
+		case utils.RPL_WHOISACCOUNT:
+			// <client> <nick> <account> :is logged in as
+			info.Account = params[2]
+		case utils.RPL_WHOISREGNICK:
+			// <client> <nick> :has identified for this nick
+			info.Registered = true
+		case utils.RPL_WHOISACTUALLY:
+			// <client> <nick> [<host> [<ip>]] :Is actually...
+			info.Actually = strings.Join(params[2:len(params)-1], " ")
+		case utils.RPL_WHOISHOST:
+			// <client> <nick> :is connecting from *@localhost 127.0.0.1
+			info.Extra = append(info.Extra, last)
+		case utils.RPL_WHOISMODES:
+			// <client> <nick> :is using modes +ailosw
+			info.Modes = last
+		case utils.RPL_WHOISSECURE:
+			// <client> <nick> :is using a secure connection
+			info.Secure = true
+		case utils.RPL_WHOISCERTFP:
+			// <client> <nick> :has client certificate fingerprint <fingerprint>
+			fields := strings.Fields(last)
+			if len(fields) > 0 {
+				info.CertFP = fields[len(fields)-1]
+			}
+		case utils.RPL_AWAY:
+			// <client> <nick> :<message>
+			info.Away = last
+		default:
+			info.Extra = append(info.Extra, last)
+		}
+	}
+
+	return info
+}
+
+func newWhoResult(reply *Reply) *WhoResult {
+	result := &WhoResult{
+		Mask:     reply.Target,
+		Entries:  make([]*WhoEntry, 0),
+		TimedOut: reply.TimedOut,
+	}
+
+	for _, message := range reply.Messages {
+		// <client> <channel> <username> <host> <server> <nick> <flags> :<hopcount> <realname>
+		params := message.Parameters
+		if message.Command != utils.RPL_WHOREPLY || len(params) < 8 {
+			continue
+		}
+		hops, realName, _ := strings.Cut(params[7], " ")
+		entry := &WhoEntry{
+			Channel:  params[1],
+			User:     params[2],
+			Host:     params[3],
+			Server:   params[4],
+			Nick:     params[5],
+			Flags:    params[6],
+			RealName: realName,
+		}
+		entry.Hops, _ = strconv.Atoi(hops)
+		result.Entries = append(result.Entries, entry)
+	}
+
+	return result
+}
+
+func (w *WhoEntry) Away() bool {
+	return strings.HasPrefix(w.Flags, "G")
+}
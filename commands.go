@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"ribbirc/utils"
+	"strings"
+)
+
+func (a *Application) handleInput(input string) {
+	if !strings.HasPrefix(input, "/") && !a.bufferOpen(a.pane.buffer) {
+		a.server.GetLogger().Append("System", utils.LogError, fmt.Sprintf(notJoinedFmt, a.pane.buffer))
+		return
+	}
+	if !a.handleCommand(input) {
+		a.server.HandleUserInput(input, a.pane.buffer)
+	}
+}
+
+func (a *Application) handleCommand(input string) bool {
+	parts := strings.Split(input, " ")
+
+	switch parts[0] {
+	case "/search":
+		if len(parts) > 2 && parts[1] == "-all" {
+			a.searchAll(strings.Join(parts[2:], " "))
+		} else if len(parts) > 1 {
+			a.startSearch(strings.Join(parts[1:], " "), false)
+		} else {
+			a.startSearch("", true)
+		}
+	case "/mentions":
+		a.showMentions()
+	case "/urls":
+		a.showURLs()
+	case "/bind":
+		a.bindCommand(parts[1:])
+	case "/theme":
+		a.themeCommand(strings.Join(parts[1:], " "))
+	case "/filter":
+		option := ""
+		if len(parts) > 1 {
+			option = parts[1]
+		}
+		a.toggleFilter(option)
+	case "/query":
+		if len(parts) < 2 || parts[1] == "" {
+			return false
+		}
+		a.switchBuffer(a.server.OpenQuery(parts[1]))
+		if len(parts) > 2 {
+			a.server.HandleUserInput(strings.Join(append([]string{"/msg"}, parts[1:]...), " "), a.pane.buffer)
+		}
+	case "/close":
+		a.closeBuffer(a.pane.buffer)
+	case "/clear":
+		a.clearBuffer()
+	case "/window":
+		a.windowCommand(parts[1:])
+	case "/quit":
+		a.quit(strings.Join(parts[1:], " "))
+	case "/mute", "/unmute":
+		buffer := ""
+		if len(parts) > 1 {
+			buffer = parts[1]
+		}
+		a.setMuted(buffer, parts[0] == "/mute")
+	default:
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+type Config struct {
+	AutoAway      AutoAway          `json:"auto_away"`
+	Logs          Logs              `json:"logs"`
+	Scrollback    Scrollback        `json:"scrollback"`
+	Highlight     Highlight         `json:"highlight"`
+	Notifications Notifications     `json:"notifications"`
+	Filter        Filter            `json:"filter"`
+	NickColors    NickColors        `json:"nick_colors"`
+	Theme         string            `json:"theme"`
+	Keys          map[string]string `json:"keys"`
+	QuitMessage   string            `json:"quit_message"`
+	URLOpener     []string          `json:"url_opener"`
+	StatusFormat  string            `json:"status_format"`
+}
+
+type AutoAway struct {
+	IdleMinutes int    `json:"idle_minutes"`
+	Message     string `json:"message"`
+}
+
+type Logs struct {
+	Enabled bool   `json:"enabled"`
+	Joins   bool   `json:"joins"`
+	Dir     string `json:"dir"`
+	Restore int    `json:"restore"`
+}
+
+type Scrollback struct {
+	Status  int `json:"status"`
+	Channel int `json:"channel"`
+	Query   int `json:"query"`
+}
+
+type Highlight struct {
+	Nick     bool     `json:"nick"`
+	Keywords []string `json:"keywords"`
+	Regexes  []string `json:"regexes"`
+	Exclude  []string `json:"exclude"`
+}
+
+type Notifications struct {
+	Method     string     `json:"method"`
+	Command    []string   `json:"command"`
+	Mute       []string   `json:"mute"`
+	QuietHours QuietHours `json:"quiet_hours"`
+}
+
+type QuietHours struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+type Filter struct {
+	Smart        bool `json:"smart"`
+	SmartMinutes int  `json:"smart_minutes"`
+	Collapse     bool `json:"collapse"`
+}
+
+type NickColors struct {
+	Enabled    bool     `json:"enabled"`
+	Palette    []string `json:"palette"`
+	Background string   `json:"background"`
+}
+
+func Default() *Config {
+	return &Config{
+		AutoAway: AutoAway{
+			IdleMinutes: 0,
+			Message:     "Auto-away",
+		},
+		Logs: Logs{
+			Enabled: true,
+			Joins:   true,
+			Restore: 50,
+		},
+		Scrollback: Scrollback{
+			Status:  2000,
+			Channel: 5000,
+			Query:   2000,
+		},
+		Highlight: Highlight{
+			Nick: true,
+		},
+		Notifications: Notifications{
+			Method: "bell",
+		},
+		Filter: Filter{
+			SmartMinutes: 10,
+		},
+		NickColors: NickColors{
+			Enabled: true,
+		},
+		Theme:        "dark",
+		QuitMessage:  "RibbIRC",
+		StatusFormat: "{nick} {modes} {network} {state} {lag} {queue} {away} {clock}",
+	}
+}
+
+func Dir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ribbirc"), nil
+}
+
+func DataDir() (string, error) {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dir, "ribbirc"), nil
+}
+
+func (c *Config) LogsDir() (string, error) {
+	if c.Logs.Dir != "" {
+		return c.Logs.Dir, nil
+	}
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "logs"), nil
+}
+
+func Load() (*Config, error) {
+	config := Default()
+
+	dir, err := Dir()
+	if err != nil {
+		return config, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, config)
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
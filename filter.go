@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"ribbirc/utils"
+	"strings"
+)
+
+type bufferFilter struct {
+	smart    bool
+	collapse bool
+}
+
+type runEntry struct {
+	position int
+	log      utils.Log
+}
+
+func (a *Application) filterFor(buffer string) bufferFilter {
+	if filter, ok := a.filters[strings.ToLower(buffer)]; ok {
+		return filter
+	}
+	return bufferFilter{smart: a.config.Filter.Smart, collapse: a.config.Filter.Collapse}
+}
+
+func (a *Application) toggleFilter(option string) {
+	filter := a.filterFor(a.pane.buffer)
+	switch option {
+	case "smart":
+		filter.smart = !filter.smart
+	case "collapse":
+		filter.collapse = !filter.collapse
+	case "":
+	default:
+		a.server.GetLogger().Append("System", utils.LogError, "Invalid command format, expected '/filter [smart|collapse]'.")
+		return
+	}
+	a.filters[strings.ToLower(a.pane.buffer)] = filter
+
+	text := fmt.Sprintf("Smart filter %s, collapsing %s", onOff(filter.smart), onOff(filter.collapse))
+	if logs := a.bufferLogs(a.pane.buffer); logs != nil {
+		logs.Append("*", utils.LogSystem, text)
+	} else {
+		a.server.GetLogger().Append("System", utils.LogStatus, fmt.Sprintf("%s: %s", a.pane.buffer, text))
+	}
+}
+
+func onOff(value bool) string {
+	if value {
+		return "on"
+	}
+	return "off"
+}
+
+func isMembership(log utils.Log) bool {
+	return log.Kind == utils.LogJoined || log.Kind == utils.LogLeft || log.Kind == utils.LogNick
+}
+
+func (a *Application) flushRun(visit func(position int, log utils.Log) bool) bool {
+	run := a.logsRun
+	a.logsRun = a.logsRun[:0]
+	switch len(run) {
+	case 0:
+		return true
+	case 1:
+		return visit(run[0].position, run[0].log)
+	}
+
+	summary := utils.Log{Time: run[0].log.Time, Source: "*", Kind: utils.LogSystem, Text: summarizeRun(run)}
+	return visit(run[0].position, summary)
+}
+
+func summarizeRun(run []runEntry) string {
+	joined, left, changed := make([]string, 0), make([]string, 0), make([]string, 0)
+	for i := len(run) - 1; i >= 0; i-- {
+		log := run[i].log
+		switch log.Kind {
+		case utils.LogJoined:
+			joined = append(joined, log.Source)
+		case utils.LogLeft:
+			left = append(left, log.Source)
+		case utils.LogNick:
+			changed = append(changed, strings.TrimSuffix(log.Text, "."))
+		}
+	}
+
+	parts := make([]string, 0, 3)
+	if len(joined) > 0 {
+		parts = append(parts, strings.Join(joined, ", ")+" joined")
+	}
+	if len(left) > 0 {
+		parts = append(parts, strings.Join(left, ", ")+" left")
+	}
+	parts = append(parts, changed...)
+	return strings.Join(parts, "; ")
+}
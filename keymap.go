@@ -0,0 +1,316 @@
+package main
+
+import (
+	"fmt"
+	"github.com/gdamore/tcell/v2"
+	"ribbirc/utils"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+var keyActions map[string]func(a *Application)
+
+var defaultKeys = map[string]string{
+	"Ctrl-C":     "quit",
+	"Enter":      "submit",
+	"PgUp":       "scroll_up",
+	"PgDn":       "scroll_down",
+	"Ctrl-F":     "search",
+	"Alt-m":      "mentions",
+	"Alt-u":      "urls",
+	"Alt-a":      "next_active",
+	"Tab":        "complete",
+	"Backspace":  "backspace",
+	"Backspace2": "backspace",
+	"Left":       "cursor_left",
+	"Right":      "cursor_right",
+	"Alt-Left":   "prev_buffer",
+	"Alt-Right":  "next_buffer",
+	"Ctrl-W s":   "split_horizontal",
+	"Ctrl-W v":   "split_vertical",
+	"Ctrl-W c":   "close_pane",
+	"Ctrl-W o":   "only_pane",
+	"Ctrl-W w":   "next_pane",
+	"Ctrl-W p":   "prev_pane",
+}
+
+var inputActions = []string{"complete", "backspace", "cursor_left", "cursor_right"}
+
+var keyNames = make(map[string]string)
+
+var ctrlAliases = map[string]string{
+	"H": "Backspace",
+	"I": "Tab",
+	"M": "Enter",
+	"[": "Esc",
+}
+
+func init() {
+	keyActions = map[string]func(a *Application){
+		"quit":             func(a *Application) { a.quit("") },
+		"submit":           (*Application).submitInput,
+		"scroll_up":        (*Application).scrollPageUp,
+		"scroll_down":      (*Application).scrollPageDown,
+		"search":           func(a *Application) { a.startSearch("", true) },
+		"mentions":         (*Application).showMentions,
+		"urls":             (*Application).showURLs,
+		"next_active":      (*Application).nextActiveBuffer,
+		"prev_buffer":      func(a *Application) { a.cycleBuffer(-1) },
+		"next_buffer":      func(a *Application) { a.cycleBuffer(1) },
+		"split_horizontal": func(a *Application) { a.splitPane(splitHorizontal, a.pane.buffer) },
+		"split_vertical":   func(a *Application) { a.splitPane(splitVertical, a.pane.buffer) },
+		"close_pane":       (*Application).closePane,
+		"only_pane":        (*Application).onlyPane,
+		"next_pane":        func(a *Application) { a.cyclePane(1) },
+		"prev_pane":        func(a *Application) { a.cyclePane(-1) },
+		"complete":         (*Application).completeNick,
+		"backspace":        (*Application).inputBackspace,
+		"cursor_left":      func(a *Application) { a.inputCursor = max(a.inputCursor-1, 0) },
+		"cursor_right":     func(a *Application) { a.inputCursor = min(a.inputCursor+1, len(a.inputText)) },
+	}
+	for i := 0; i <= 9; i++ {
+		action := fmt.Sprintf("buffer_%d", i)
+		keyActions[action] = func(a *Application) {
+			a.switchToTab(i)
+		}
+		defaultKeys[fmt.Sprintf("Alt-%d", i)] = action
+	}
+	for _, name := range tcell.KeyNames {
+		keyNames[strings.ToLower(name)] = name
+	}
+}
+
+type keymap struct {
+	bindings map[string]string
+}
+
+func newKeymap(overrides map[string]string) (*keymap, []string) {
+	k := &keymap{bindings: make(map[string]string)}
+	problems := make([]string, 0)
+	for sequence, action := range defaultKeys {
+		k.bindings[sequence] = action
+	}
+
+	sequences := make([]string, 0, len(overrides))
+	for sequence := range overrides {
+		sequences = append(sequences, sequence)
+	}
+	sort.Strings(sequences)
+	for _, sequence := range sequences {
+		conflicts, err := k.bind(sequence, overrides[sequence])
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+		problems = append(problems, conflicts...)
+	}
+	return k, problems
+}
+
+func (k *keymap) bind(sequence string, action string) ([]string, error) {
+	normalized, err := normalizeSequence(sequence)
+	if err != nil {
+		return nil, err
+	}
+	if action == "none" || action == "" {
+		delete(k.bindings, normalized)
+		return nil, nil
+	}
+	if _, ok := keyActions[action]; !ok {
+		return nil, fmt.Errorf("unknown action %s for %s", action, normalized)
+	}
+
+	conflicts := make([]string, 0)
+	for other, otherAction := range k.bindings {
+		switch {
+		case other == normalized && otherAction != action:
+			conflicts = append(conflicts, fmt.Sprintf("%s was bound to %s, now %s", normalized, otherAction, action))
+		case strings.HasPrefix(other, normalized+" "):
+			conflicts = append(conflicts, fmt.Sprintf("%s shadows %s (%s)", normalized, other, otherAction))
+		case strings.HasPrefix(normalized, other+" "):
+			conflicts = append(conflicts, fmt.Sprintf("%s is unreachable, %s is bound to %s", normalized, other, otherAction))
+		}
+	}
+	sort.Strings(conflicts)
+	k.bindings[normalized] = action
+	return conflicts, nil
+}
+
+func (k *keymap) lookup(sequence string) (string, bool) {
+	if action, ok := k.bindings[sequence]; ok {
+		return action, false
+	}
+	for other := range k.bindings {
+		if strings.HasPrefix(other, sequence+" ") {
+			return "", true
+		}
+	}
+	return "", false
+}
+
+func (k *keymap) list() []string {
+	lines := make([]string, 0, len(k.bindings))
+	for sequence, action := range k.bindings {
+		lines = append(lines, fmt.Sprintf("%s: %s", sequence, action))
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+func normalizeSequence(sequence string) (string, error) {
+	chords := strings.Fields(sequence)
+	if len(chords) == 0 {
+		return "", fmt.Errorf("empty key sequence")
+	}
+	for i, chord := range chords {
+		normalized, err := normalizeChord(chord)
+		if err != nil {
+			return "", err
+		}
+		chords[i] = normalized
+	}
+	return strings.Join(chords, " "), nil
+}
+
+func normalizeChord(chord string) (string, error) {
+	parts := strings.Split(chord, "-")
+	if len(parts) > 1 && parts[len(parts)-1] == "" {
+		parts = append(parts[:len(parts)-2], "-")
+	}
+
+	var ctrl, alt, shift bool
+	for _, modifier := range parts[:len(parts)-1] {
+		switch strings.ToLower(modifier) {
+		case "ctrl", "c":
+			ctrl = true
+		case "alt", "meta", "m":
+			alt = true
+		case "shift", "s":
+			shift = true
+		default:
+			return "", fmt.Errorf("unknown modifier %s in %s", modifier, chord)
+		}
+	}
+
+	key := parts[len(parts)-1]
+	if utf8.RuneCountInString(key) == 1 {
+		if ctrl {
+			key = strings.ToUpper(key)
+			if alias, ok := ctrlAliases[key]; ok {
+				return formatChord(false, alt, false, alias), nil
+			}
+			if _, ok := keyNames[strings.ToLower("Ctrl-"+key)]; !ok {
+				return "", fmt.Errorf("unknown key %s, terminals only send Ctrl with letters and a few symbols", chord)
+			}
+		}
+		return formatChord(ctrl, alt, false, key), nil
+	}
+
+	name, ok := keyNames["ctrl-"+strings.ToLower(key)]
+	if !ok || !ctrl {
+		name, ok = keyNames[strings.ToLower(key)]
+	}
+	if !ok && strings.EqualFold(key, "space") {
+		name, ok = "Space", true
+	}
+	if !ok {
+		return "", fmt.Errorf("unknown key %s", chord)
+	}
+	name, named := strings.CutPrefix(name, "Ctrl-")
+	return formatChord(ctrl || named, alt, shift, name), nil
+}
+
+func chordName(ev *tcell.EventKey) string {
+	modifiers := ev.Modifiers()
+	if ev.Key() == tcell.KeyRune {
+		key := string(ev.Rune())
+		if key == " " {
+			key = "Space"
+		}
+		return formatChord(false, modifiers&tcell.ModAlt != 0, false, key)
+	}
+
+	name, ok := tcell.KeyNames[ev.Key()]
+	if !ok {
+		name = fmt.Sprintf("Key[%d]", ev.Key())
+	}
+	name, ctrl := strings.CutPrefix(name, "Ctrl-")
+	ctrl = ctrl || modifiers&tcell.ModCtrl != 0
+	return formatChord(ctrl, modifiers&tcell.ModAlt != 0, modifiers&tcell.ModShift != 0, name)
+}
+
+func formatChord(ctrl bool, alt bool, shift bool, key string) string {
+	chord := ""
+	if ctrl {
+		chord += "Ctrl-"
+	}
+	if alt {
+		chord += "Alt-"
+	}
+	if shift {
+		chord += "Shift-"
+	}
+	return chord + key
+}
+
+func (a *Application) handleKeymap(ev *tcell.EventKey) bool {
+	sequence := strings.Join(append(a.keyPending, chordName(ev)), " ")
+	action, prefix := a.keys.lookup(sequence)
+	if prefix {
+		a.keyPending = append(a.keyPending, chordName(ev))
+		return true
+	}
+	pending := len(a.keyPending) > 0
+	a.keyPending = a.keyPending[:0]
+	if action == "" {
+		return pending
+	}
+
+	if action != "complete" {
+		a.completion = nil
+	}
+	if contains(inputActions, action) && !a.inputActive {
+		return true
+	}
+	keyActions[action](a)
+	return true
+}
+
+func (a *Application) bindCommand(args []string) {
+	logs := a.server.GetLogger()
+	if len(args) == 0 {
+		for _, line := range a.keys.list() {
+			logs.Append("System", utils.LogStatus, line)
+		}
+		return
+	}
+
+	action := ""
+	if last := args[len(args)-1]; len(args) > 1 && (keyActions[last] != nil || last == "none") {
+		action = last
+		args = args[:len(args)-1]
+	}
+	sequence, err := normalizeSequence(strings.Join(args, " "))
+	if err != nil {
+		logs.Append("System", utils.LogError, err.Error())
+		return
+	}
+	if action == "" {
+		bound, ok := a.keys.bindings[sequence]
+		if !ok {
+			bound = "unbound"
+		}
+		logs.Append("System", utils.LogStatus, fmt.Sprintf("%s: %s", sequence, bound))
+		return
+	}
+
+	conflicts, err := a.keys.bind(sequence, action)
+	if err != nil {
+		logs.Append("System", utils.LogError, err.Error())
+		return
+	}
+	for _, conflict := range conflicts {
+		logs.Append("System", utils.LogError, conflict)
+	}
+	logs.Append("System", utils.LogStatus, fmt.Sprintf("%s: %s", sequence, action))
+}
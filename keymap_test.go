@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"github.com/gdamore/tcell/v2"
+	"testing"
+)
+
+func TestNormalizeSequence(t *testing.T) {
+	tests := map[string]struct {
+		sequence string
+		output   string
+		err      bool
+	}{
+		"Letter":        {"ctrl-f", "Ctrl-F", false},
+		"ShortCtrl":     {"C-w", "Ctrl-W", false},
+		"Alt":           {"meta-m", "Alt-m", false},
+		"ShortAlt":      {"M-x", "Alt-x", false},
+		"Named":         {"pgup", "PgUp", false},
+		"CtrlNamed":     {"Ctrl-Left", "Ctrl-Left", false},
+		"ShiftNamed":    {"Shift-Right", "Shift-Right", false},
+		"Dash":          {"Alt--", "Alt--", false},
+		"Space":         {"Ctrl-space", "Ctrl-Space", false},
+		"Sequence":      {"ctrl-w   s", "Ctrl-W s", false},
+		"CtrlH":         {"Ctrl-H", "Backspace", false},
+		"CtrlI":         {"ctrl-i", "Tab", false},
+		"CtrlM":         {"C-m", "Enter", false},
+		"CtrlBracket":   {"Ctrl-[", "Esc", false},
+		"AltCtrlH":      {"Alt-Ctrl-h", "Alt-Backspace", false},
+		"Empty":         {"   ", "", true},
+		"CtrlDigit":     {"Ctrl-1", "", true},
+		"UnknownKey":    {"Ctrl-Nope", "", true},
+		"UnknownMod":    {"Hyper-x", "", true},
+		"BadSecondHalf": {"Ctrl-W Super-s", "", true},
+	}
+
+	fails := 0
+	for testName, test := range tests {
+		t.Logf("Running test %s...", testName)
+
+		output, err := normalizeSequence(test.sequence)
+		if output == test.output && (err != nil) == test.err {
+			t.Logf("  PASS")
+		} else {
+			t.Logf("  FAIL: Expected '%s' (error %t), got '%s' (%v)", test.output, test.err, output, err)
+			fails++
+		}
+	}
+
+	if fails > 0 {
+		t.Fatalf("Failed %d/%d tests", fails, len(tests))
+	}
+}
+
+func TestChordName(t *testing.T) {
+	tests := map[string]struct {
+		key   tcell.Key
+		ch    rune
+		mod   tcell.ModMask
+		chord string
+	}{
+		"Rune":      {tcell.KeyRune, 'x', tcell.ModNone, "x"},
+		"AltRune":   {tcell.KeyRune, 'm', tcell.ModAlt, "Alt-m"},
+		"Space":     {tcell.KeyRune, ' ', tcell.ModNone, "Space"},
+		"Ctrl":      {tcell.KeyRune, 0x17, tcell.ModNone, "Ctrl-W"},
+		"CtrlH":     {tcell.KeyRune, 0x08, tcell.ModNone, "Ctrl-H"},
+		"CtrlM":     {tcell.KeyRune, 0x0d, tcell.ModNone, "C-m"},
+		"CtrlArrow": {tcell.KeyLeft, 0, tcell.ModCtrl, "Ctrl-Left"},
+		"PgUp":      {tcell.KeyPgUp, 0, tcell.ModNone, "PgUp"},
+	}
+
+	fails := 0
+	for testName, test := range tests {
+		t.Logf("Running test %s...", testName)
+
+		expected, err := normalizeSequence(test.chord)
+		output := chordName(tcell.NewEventKey(test.key, test.ch, test.mod))
+		if err == nil && output == expected {
+			t.Logf("  PASS")
+		} else {
+			t.Logf("  FAIL: Expected '%s', got '%s' (%v)", expected, output, err)
+			fails++
+		}
+	}
+
+	if fails > 0 {
+		t.Fatalf("Failed %d/%d tests", fails, len(tests))
+	}
+}
+
+func TestKeymapBind(t *testing.T) {
+	tests := map[string]struct {
+		bindings  map[string]string
+		sequence  string
+		action    string
+		conflicts []string
+		err       bool
+		bound     bool
+	}{
+		"New": {
+			bindings:  map[string]string{"Ctrl-F": "search"},
+			sequence:  "Alt-x",
+			action:    "mentions",
+			conflicts: []string{},
+			bound:     true,
+		},
+		"Same": {
+			bindings:  map[string]string{"Ctrl-F": "search"},
+			sequence:  "ctrl-f",
+			action:    "search",
+			conflicts: []string{},
+			bound:     true,
+		},
+		"Rebind": {
+			bindings:  map[string]string{"Ctrl-F": "search"},
+			sequence:  "ctrl-f",
+			action:    "mentions",
+			conflicts: []string{"Ctrl-F was bound to search, now mentions"},
+			bound:     true,
+		},
+		"Shadows": {
+			bindings:  map[string]string{"Ctrl-W s": "split_horizontal", "Ctrl-W v": "split_vertical"},
+			sequence:  "Ctrl-W",
+			action:    "quit",
+			conflicts: []string{"Ctrl-W shadows Ctrl-W s (split_horizontal)", "Ctrl-W shadows Ctrl-W v (split_vertical)"},
+			bound:     true,
+		},
+		"Unreachable": {
+			bindings:  map[string]string{"Ctrl-W": "quit"},
+			sequence:  "Ctrl-W v",
+			action:    "split_vertical",
+			conflicts: []string{"Ctrl-W v is unreachable, Ctrl-W is bound to quit"},
+			bound:     true,
+		},
+		"Unbind": {
+			bindings:  map[string]string{"Ctrl-F": "search"},
+			sequence:  "Ctrl-F",
+			action:    "none",
+			conflicts: nil,
+		},
+		"UnknownAction": {
+			bindings:  map[string]string{},
+			sequence:  "Ctrl-F",
+			action:    "fly",
+			conflicts: nil,
+			err:       true,
+		},
+	}
+
+	fails := 0
+	for testName, test := range tests {
+		t.Logf("Running test %s...", testName)
+
+		k := &keymap{bindings: make(map[string]string)}
+		for sequence, action := range test.bindings {
+			k.bindings[sequence] = action
+		}
+		conflicts, err := k.bind(test.sequence, test.action)
+		normalized, _ := normalizeSequence(test.sequence)
+		_, bound := k.bindings[normalized]
+		if fmt.Sprint(conflicts) == fmt.Sprint(test.conflicts) && (err != nil) == test.err && bound == test.bound {
+			t.Logf("  PASS")
+		} else {
+			t.Logf("  FAIL: Expected %v (error %t, bound %t), got %v (%v, bound %t)", test.conflicts, test.err, test.bound, conflicts, err, bound)
+			fails++
+		}
+	}
+
+	if fails > 0 {
+		t.Fatalf("Failed %d/%d tests", fails, len(tests))
+	}
+}
+
+func TestKeymapLookup(t *testing.T) {
+	k := &keymap{bindings: map[string]string{
+		"Ctrl-W s": "split_horizontal",
+		"Alt-m":    "mentions",
+		"Ctrl-X a": "urls",
+	}}
+
+	tests := map[string]struct {
+		sequence string
+		action   string
+		prefix   bool
+	}{
+		"Bound":        {"Alt-m", "mentions", false},
+		"Prefix":       {"Ctrl-W", "", true},
+		"Sequence":     {"Ctrl-W s", "split_horizontal", false},
+		"WrongSecond":  {"Ctrl-W x", "", false},
+		"PartialChord": {"Ctrl", "", false},
+		"Unbound":      {"Alt-z", "", false},
+	}
+
+	fails := 0
+	for testName, test := range tests {
+		t.Logf("Running test %s...", testName)
+
+		action, prefix := k.lookup(test.sequence)
+		if action == test.action && prefix == test.prefix {
+			t.Logf("  PASS")
+		} else {
+			t.Logf("  FAIL: Expected '%s' %t, got '%s' %t", test.action, test.prefix, action, prefix)
+			fails++
+		}
+	}
+
+	if fails > 0 {
+		t.Fatalf("Failed %d/%d tests", fails, len(tests))
+	}
+}
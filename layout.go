@@ -0,0 +1,288 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"ribbirc/config"
+	"ribbirc/utils"
+)
+
+const (
+	splitHorizontal = "horizontal"
+	splitVertical   = "vertical"
+)
+
+type pane struct {
+	buffer string
+	offset int
+	area   area
+}
+
+type layoutNode struct {
+	Split  string      `json:"split,omitempty"`
+	First  *layoutNode `json:"first,omitempty"`
+	Second *layoutNode `json:"second,omitempty"`
+	Buffer string      `json:"buffer,omitempty"`
+
+	pane *pane
+	area area
+}
+
+func newLayout() *layoutNode {
+	return &layoutNode{pane: &pane{}}
+}
+
+func loadLayout(path string) (*layoutNode, error) {
+	if path == "" {
+		return newLayout(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return newLayout(), nil
+	}
+	if err != nil {
+		return newLayout(), err
+	}
+
+	root := &layoutNode{}
+	err = json.Unmarshal(data, root)
+	if err != nil {
+		return newLayout(), err
+	}
+	if err := root.restore(); err != nil {
+		return newLayout(), err
+	}
+	return root, nil
+}
+
+func (n *layoutNode) restore() error {
+	switch n.Split {
+	case "":
+		n.First, n.Second = nil, nil
+		n.pane = &pane{buffer: n.Buffer}
+		return nil
+	case splitHorizontal, splitVertical:
+		if n.First == nil || n.Second == nil {
+			return fmt.Errorf("%s split without two panes", n.Split)
+		}
+		if err := n.First.restore(); err != nil {
+			return err
+		}
+		return n.Second.restore()
+	}
+	return fmt.Errorf("unknown split %s", n.Split)
+}
+
+func (n *layoutNode) save(path string) error {
+	if path == "" {
+		return nil
+	}
+	for _, leaf := range n.leaves() {
+		leaf.Buffer = leaf.pane.buffer
+	}
+	data, err := json.MarshalIndent(n, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func layoutPath() string {
+	dir, err := config.DataDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "layout.json")
+}
+
+func (n *layoutNode) leaves() []*layoutNode {
+	if n.pane != nil {
+		return []*layoutNode{n}
+	}
+	return append(n.First.leaves(), n.Second.leaves()...)
+}
+
+func (n *layoutNode) panes() []*pane {
+	leaves := n.leaves()
+	panes := make([]*pane, 0, len(leaves))
+	for _, leaf := range leaves {
+		panes = append(panes, leaf.pane)
+	}
+	return panes
+}
+
+func (n *layoutNode) find(p *pane) (*layoutNode, *layoutNode) {
+	if n.pane == p {
+		return n, nil
+	}
+	if n.pane != nil {
+		return nil, nil
+	}
+	for _, child := range []*layoutNode{n.First, n.Second} {
+		if node, parent := child.find(p); node != nil {
+			if parent == nil {
+				parent = n
+			}
+			return node, parent
+		}
+	}
+	return nil, nil
+}
+
+func (n *layoutNode) split(p *pane, direction string, buffer string) *pane {
+	node, _ := n.find(p)
+	if node == nil {
+		return nil
+	}
+	created := &pane{buffer: buffer}
+	node.Split = direction
+	node.First = &layoutNode{pane: p}
+	node.Second = &layoutNode{pane: created}
+	node.Buffer = ""
+	node.pane = nil
+	return created
+}
+
+func (n *layoutNode) close(p *pane) *pane {
+	_, parent := n.find(p)
+	if parent == nil {
+		return nil
+	}
+	sibling := parent.First
+	if sibling.pane == p {
+		sibling = parent.Second
+	}
+	*parent = *sibling
+	return parent.leaves()[0].pane
+}
+
+func (n *layoutNode) arrange(r area) {
+	n.area = r
+	switch {
+	case n.pane != nil:
+		n.pane.area = r
+	case n.Split == splitVertical:
+		width := (r.width - 1) / 2
+		n.First.arrange(area{r.x, r.y, width, r.height})
+		n.Second.arrange(area{r.x + width + 1, r.y, r.width - width - 1, r.height})
+	default:
+		height := r.height / 2
+		n.First.arrange(area{r.x, r.y, r.width, height})
+		n.Second.arrange(area{r.x, r.y + height, r.width, r.height - height})
+	}
+}
+
+func (a *Application) paneViewport(p *pane) area {
+	if a.layout.pane != nil {
+		return p.area
+	}
+	return area{p.area.x, p.area.y + 1, p.area.width, p.area.height - 1}
+}
+
+func (a *Application) drawPanes() {
+	a.layout.arrange(a.logsArea())
+	a.drawSeparators(a.layout)
+	for _, p := range a.layout.panes() {
+		a.drawLogs(p)
+		if a.layout.pane == nil {
+			a.drawPaneHeader(p)
+		}
+	}
+}
+
+func (a *Application) drawSeparators(n *layoutNode) {
+	if n.pane != nil {
+		return
+	}
+	if n.Split == splitVertical {
+		col := n.First.area.x + n.First.area.width
+		style := a.theme.style("default")
+		for row := n.area.y; row < n.area.y+n.area.height; row++ {
+			a.screen.SetContent(col, row, '│', nil, style)
+		}
+	}
+	a.drawSeparators(n.First)
+	a.drawSeparators(n.Second)
+}
+
+func (a *Application) drawPaneHeader(p *pane) {
+	style := a.theme.style("bar")
+	if p == a.pane {
+		style = a.theme.apply(style, "tab_active")
+	}
+	for col := p.area.x; col < p.area.x+p.area.width; col++ {
+		a.screen.SetContent(col, p.area.y, ' ', nil, style)
+	}
+	title := p.buffer
+	if title == "" {
+		title = "Status"
+	}
+	a.drawStringClip(p.area.x+1, p.area.y, p.area.width-1, title, style)
+}
+
+func (a *Application) bufferOpen(buffer string) bool {
+	return a.bufferLogs(buffer) != nil
+}
+
+func (a *Application) bufferVisible(buffer string) bool {
+	for _, p := range a.layout.panes() {
+		if p.buffer == buffer {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Application) focusPane(p *pane) {
+	a.pane = p
+	a.stopSearch()
+	a.server.SetActiveBuffer(p.buffer)
+}
+
+func (a *Application) cyclePane(step int) {
+	panes := a.layout.panes()
+	for i, p := range panes {
+		if p == a.pane {
+			a.focusPane(panes[(i+step+len(panes))%len(panes)])
+			return
+		}
+	}
+}
+
+func (a *Application) splitPane(direction string, buffer string) {
+	created := a.layout.split(a.pane, direction, buffer)
+	if created == nil {
+		return
+	}
+	a.focusPane(created)
+	a.saveLayout()
+}
+
+func (a *Application) closePane() {
+	next := a.layout.close(a.pane)
+	if next == nil {
+		a.server.GetLogger().Append("System", utils.LogError, "The last pane cannot be closed.")
+		return
+	}
+	a.focusPane(next)
+	a.saveLayout()
+}
+
+func (a *Application) onlyPane() {
+	a.layout = &layoutNode{pane: a.pane}
+	a.saveLayout()
+}
+
+func (a *Application) saveLayout() {
+	if err := a.layout.save(layoutPath()); err != nil {
+		a.server.GetLogger().Append("System", utils.LogError, fmt.Sprintf("Could not save layout: %s", err))
+	}
+}
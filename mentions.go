@@ -0,0 +1,19 @@
+package main
+
+import (
+	"ribbirc/client"
+)
+
+const maxMentions = 1000
+
+func (a *Application) addMention(event *client.HighlightEvent) {
+	a.mentions = append(a.mentions, jumpEntry{event.Buffer, event.Position, event.Log})
+	if len(a.mentions) > maxMentions {
+		a.mentions = a.mentions[len(a.mentions)-maxMentions:]
+	}
+}
+
+func (a *Application) showMentions() {
+	entries := append([]jumpEntry{}, a.mentions...)
+	a.openView(a.pane.buffer, newJumpView("Mentions", entries, ""))
+}
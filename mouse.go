@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/gdamore/tcell/v2"
+	"strings"
+)
+
+type hotspot struct {
+	area   area
+	action func(a *Application)
+}
+
+type selection struct {
+	pane     *pane
+	startCol int
+	startRow int
+	endCol   int
+	endRow   int
+	dragged  bool
+}
+
+type menuItem struct {
+	label  string
+	action func(a *Application)
+}
+
+type menuView struct {
+	title    string
+	items    []menuItem
+	x        int
+	y        int
+	selected int
+}
+
+func (a *Application) addHotspot(x int, y int, width int, action func(a *Application)) {
+	if a.clip != nil && !a.clip.contains(x, y) {
+		return
+	}
+	a.hotspots = append(a.hotspots, hotspot{area{x, y, width, 1}, action})
+}
+
+func (a *Application) addNickHotspot(x int, y int, nick string, buffer string) {
+	if nick == "" || nick == "*" {
+		return
+	}
+	a.addHotspot(x, y, stringWidth(nick), func(a *Application) {
+		a.openView(a.pane.buffer, a.nickMenu(nick, buffer, x, y))
+	})
+}
+
+func (a *Application) nickMenu(nick string, buffer string, x int, y int) *menuView {
+	items := []menuItem{
+		{"Query", func(a *Application) { a.switchBuffer(a.server.OpenQuery(nick)) }},
+		{"Whois", func(a *Application) { a.server.HandleUserInput("/whois "+nick, buffer) }},
+	}
+	if channel, err := a.server.GetChannel(buffer); err == nil && !channel.Query {
+		items = append(items, menuItem{"Kick", func(a *Application) {
+			a.server.HandleUserInput(fmt.Sprintf("/kick %s %s", buffer, nick), buffer)
+		}})
+	}
+	items = append(items, menuItem{"Ignore", func(a *Application) {
+		a.server.HandleUserInput(fmt.Sprintf("/ignore %s!*@*", nick), buffer)
+	}})
+	return &menuView{title: nick, items: items, x: x, y: y + 1}
+}
+
+func (v *menuView) draw(a *Application, x int, y int, width int, height int) {
+	boxWidth := stringWidth(v.title) + 6
+	for _, item := range v.items {
+		boxWidth = max(boxWidth, stringWidth(item.label)+4)
+	}
+	boxWidth = min(boxWidth, width)
+	boxHeight := min(len(v.items)+2, height)
+	boxX := max(x, min(v.x, x+width-boxWidth))
+	boxY := max(y, min(v.y, y+height-boxHeight))
+
+	style := a.theme.style("view")
+	a.drawBox(boxX, boxY, boxWidth, boxHeight, v.title, style)
+	for i, item := range v.items {
+		row := boxY + 1 + i
+		if row >= boxY+boxHeight-1 {
+			break
+		}
+		itemStyle := style
+		if i == v.selected {
+			itemStyle = a.theme.apply(style, "view_selected")
+		}
+		for col := boxX + 1; col < boxX+boxWidth-1; col++ {
+			a.screen.SetContent(col, row, ' ', nil, itemStyle)
+		}
+		a.drawStringClip(boxX+2, row, boxWidth-4, item.label, itemStyle)
+		a.addHotspot(boxX+1, row, boxWidth-2, func(a *Application) {
+			a.closeView()
+			item.action(a)
+		})
+	}
+}
+
+func (v *menuView) handleKey(a *Application, ev *tcell.EventKey) bool {
+	switch ev.Key() {
+	case tcell.KeyEscape:
+		return true
+	case tcell.KeyUp:
+		v.selected = max(0, v.selected-1)
+	case tcell.KeyDown:
+		v.selected = min(len(v.items)-1, v.selected+1)
+	case tcell.KeyEnter:
+		a.closeView()
+		v.items[v.selected].action(a)
+		return false
+	}
+	return false
+}
+
+func (a *Application) handleMouseEvent(ev *tcell.EventMouse) {
+	col, row := ev.Position()
+	target := a.paneAt(col, row)
+	if target == nil {
+		target = a.pane
+	}
+
+	if ev.Buttons()&tcell.WheelUp > 0 {
+		a.scrollPane(target, wheelRows)
+	}
+
+	if ev.Buttons()&tcell.WheelDown > 0 {
+		a.scrollPane(target, -wheelRows)
+	}
+
+	switch {
+	case ev.Buttons()&tcell.Button1 != 0:
+		if a.selection == nil {
+			a.selection = &selection{pane: a.paneAt(col, row), startCol: col, startRow: row, endCol: col, endRow: row}
+			return
+		}
+		if col != a.selection.endCol || row != a.selection.endRow {
+			a.selection.endCol, a.selection.endRow = col, row
+			a.selection.dragged = a.selection.pane != nil
+		}
+	case ev.Buttons() == tcell.ButtonNone && a.selection != nil:
+		s := a.selection
+		a.selection = nil
+		if s.dragged {
+			a.copySelection(s)
+		} else {
+			a.click(col, row)
+		}
+	}
+}
+
+func (a *Application) paneAt(col int, row int) *pane {
+	if a.currentView() != nil {
+		return nil
+	}
+	for _, p := range a.layout.panes() {
+		if a.paneViewport(p).contains(col, row) {
+			return p
+		}
+	}
+	return nil
+}
+
+func (a *Application) click(col int, row int) {
+	if p := a.paneAt(col, row); p != nil && p != a.pane {
+		a.focusPane(p)
+	}
+	for i := len(a.hotspots) - 1; i >= 0; i-- {
+		if a.hotspots[i].area.contains(col, row) {
+			a.hotspots[i].action(a)
+			return
+		}
+	}
+	if _, ok := a.currentView().(*menuView); ok {
+		a.closeView()
+	}
+}
+
+func (s *selection) cells(a *Application, visit func(col int, row int)) {
+	viewport := a.paneViewport(s.pane)
+	startCol, startRow, endCol, endRow := s.startCol, s.startRow, s.endCol, s.endRow
+	if endRow < startRow || (endRow == startRow && endCol < startCol) {
+		startCol, startRow, endCol, endRow = endCol, endRow, startCol, startRow
+	}
+	for row := max(startRow, viewport.y); row <= min(endRow, viewport.y+viewport.height-1); row++ {
+		from, to := viewport.x, viewport.x+viewport.width-1
+		if row == startRow {
+			from = max(from, startCol)
+		}
+		if row == endRow {
+			to = min(to, endCol)
+		}
+		for col := from; col <= to; col++ {
+			visit(col, row)
+		}
+	}
+}
+
+func (a *Application) drawSelection() {
+	if a.selection == nil || !a.selection.dragged {
+		return
+	}
+	a.selection.cells(a, func(col int, row int) {
+		mainc, combc, style, _ := a.screen.GetContent(col, row)
+		a.screen.SetContent(col, row, mainc, combc, style.Reverse(true))
+	})
+}
+
+func (a *Application) copySelection(s *selection) {
+	lines := make([]string, 0)
+	var line strings.Builder
+	lastRow, skip := -1, 0
+	s.cells(a, func(col int, row int) {
+		if row != lastRow {
+			if lastRow >= 0 {
+				lines = append(lines, strings.TrimRight(line.String(), " "))
+				line.Reset()
+			}
+			lastRow, skip = row, 0
+		}
+		if skip > 0 {
+			skip--
+			return
+		}
+		mainc, combc, _, width := a.screen.GetContent(col, row)
+		line.WriteRune(mainc)
+		for _, r := range combc {
+			line.WriteRune(r)
+		}
+		skip = width - 1
+	})
+	if lastRow >= 0 {
+		lines = append(lines, strings.TrimRight(line.String(), " "))
+	}
+
+	text := strings.Join(lines, "\n")
+	if text == "" {
+		return
+	}
+	a.writeTerminal(fmt.Sprintf("\x1b]52;c;%s\x07", base64.StdEncoding.EncodeToString([]byte(text))))
+}
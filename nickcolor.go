@@ -0,0 +1,165 @@
+package main
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"hash/fnv"
+	"math"
+	"regexp"
+)
+
+const minNickContrast = 2.0
+
+var nickWordPattern = regexp.MustCompile("[\\w\\[\\]\\\\^{}|`-]+")
+
+var basicNickColors = []tcell.Color{
+	tcell.ColorMaroon, tcell.ColorGreen, tcell.ColorOlive, tcell.ColorNavy,
+	tcell.ColorPurple, tcell.ColorTeal, tcell.ColorRed, tcell.ColorLime,
+	tcell.ColorYellow, tcell.ColorBlue, tcell.ColorFuchsia, tcell.ColorAqua,
+}
+
+type styleSpan struct {
+	start int
+	end   int
+	style tcell.Style
+	url   string
+}
+
+func (a *Application) initNickColors() {
+	cfg := a.config.NickColors
+	a.nickPalette = nil
+	if !cfg.Enabled {
+		return
+	}
+
+	background := a.theme.background
+	if cfg.Background != "" {
+		background, _ = parseColor(cfg.Background)
+	}
+	candidates := make([]tcell.Color, 0)
+	for _, name := range cfg.Palette {
+		if color, ok := parseColor(name); ok {
+			candidates = append(candidates, color)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = defaultNickPalette(a.screen.Colors(), background)
+	}
+
+	a.nickPalette = make([]tcell.Color, 0, len(candidates))
+	for _, color := range candidates {
+		if contrast(color, background) >= minNickContrast {
+			a.nickPalette = append(a.nickPalette, color)
+		}
+	}
+	if len(a.nickPalette) == 0 {
+		a.nickPalette = candidates
+	}
+}
+
+func defaultNickPalette(colors int, background tcell.Color) []tcell.Color {
+	palette := make([]tcell.Color, 0)
+	switch {
+	case colors >= 1<<24:
+		lightness := 0.65
+		if luminance(background) > 0.5 {
+			lightness = 0.35
+		}
+		for hue := 0; hue < 360; hue += 15 {
+			palette = append(palette, hslColor(float64(hue), 0.6, lightness))
+		}
+	case colors >= 256:
+		for r := 0; r < 6; r++ {
+			for g := 0; g < 6; g++ {
+				for b := 0; b < 6; b++ {
+					if max(r, g, b)-min(r, g, b) >= 2 {
+						palette = append(palette, tcell.PaletteColor(16+36*r+6*g+b))
+					}
+				}
+			}
+		}
+	default:
+		palette = append(palette, basicNickColors...)
+	}
+	return palette
+}
+
+func (a *Application) nickStyle(style tcell.Style, nick string) tcell.Style {
+	if len(a.nickPalette) == 0 || nick == "" || nick == "*" {
+		return style
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(a.server.FoldNick(nick)))
+	return style.Foreground(a.nickPalette[hash.Sum32()%uint32(len(a.nickPalette))])
+}
+
+func (a *Application) mentionSpans(text string, style tcell.Style) []styleSpan {
+	if len(a.nickPalette) == 0 || len(a.memberNicks) == 0 {
+		return nil
+	}
+	spans := make([]styleSpan, 0)
+	for _, match := range nickWordPattern.FindAllStringIndex(text, -1) {
+		nick := text[match[0]:match[1]]
+		if a.memberNicks[a.server.FoldNick(nick)] {
+			spans = append(spans, styleSpan{start: match[0], end: match[1], style: a.nickStyle(style, nick)})
+		}
+	}
+	return spans
+}
+
+func (a *Application) updateMemberNicks(buffer string) {
+	clear(a.memberNicks)
+	if channel, err := a.server.GetChannel(buffer); err == nil {
+		for _, member := range channel.Members() {
+			a.memberNicks[a.server.FoldNick(member.Nick)] = true
+		}
+	}
+}
+
+func luminance(color tcell.Color) float64 {
+	r, g, b := color.RGB()
+	if r < 0 {
+		return 0
+	}
+	channel := func(value int32) float64 {
+		c := float64(value) / 255
+		if c <= 0.03928 {
+			return c / 12.92
+		}
+		return math.Pow((c+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(r) + 0.7152*channel(g) + 0.0722*channel(b)
+}
+
+func contrast(foreground tcell.Color, background tcell.Color) float64 {
+	if r, _, _ := background.RGB(); r < 0 {
+		return math.Inf(1)
+	}
+	l1, l2 := luminance(foreground), luminance(background)
+	if l1 < l2 {
+		l1, l2 = l2, l1
+	}
+	return (l1 + 0.05) / (l2 + 0.05)
+}
+
+func hslColor(hue float64, saturation float64, lightness float64) tcell.Color {
+	c := (1 - math.Abs(2*lightness-1)) * saturation
+	x := c * (1 - math.Abs(math.Mod(hue/60, 2)-1))
+	m := lightness - c/2
+
+	var r, g, b float64
+	switch {
+	case hue < 60:
+		r, g, b = c, x, 0
+	case hue < 120:
+		r, g, b = x, c, 0
+	case hue < 180:
+		r, g, b = 0, c, x
+	case hue < 240:
+		r, g, b = 0, x, c
+	case hue < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	return tcell.NewRGBColor(int32((r+m)*255), int32((g+m)*255), int32((b+m)*255))
+}
@@ -0,0 +1,77 @@
+package main
+
+const nickListWidth = 18
+
+func (a *Application) nickListShown() bool {
+	return a.showNickList && a.currentChannel() != nil && a.width >= 4*nickListWidth
+}
+
+func (a *Application) logsWidth() int {
+	if a.nickListShown() {
+		return a.width - nickListWidth
+	}
+	return a.width
+}
+
+func (a *Application) drawNickList() {
+	if !a.nickListShown() {
+		return
+	}
+
+	style := a.theme.style("nicklist")
+	awayStyle := a.theme.apply(style, "nicklist_away")
+	x := a.width - nickListWidth
+	for row := 1; row < a.height-2; row++ {
+		for col := x; col < a.width; col++ {
+			a.screen.SetContent(col, row, ' ', nil, style)
+		}
+		a.screen.SetContent(x, row, '│', nil, style)
+	}
+
+	members := a.currentChannel().Members()
+	for i, member := range members {
+		row := 1 + i
+		if row >= a.height-2 {
+			break
+		}
+		nickStyle := a.nickStyle(style, member.Nick)
+		if user, ok := a.server.User(member.Nick); ok && user.Away {
+			nickStyle = awayStyle
+		}
+		prefix := " "
+		if member.Prefix != "" {
+			prefix = member.Prefix[:1]
+		}
+		a.drawStringClip(x+2, row, nickListWidth-2, prefix+member.Nick, nickStyle)
+		a.addNickHotspot(x+3, row, member.Nick, a.pane.buffer)
+	}
+}
+
+func (a *Application) completeNick() {
+	if a.completion == nil {
+		a.completionStart = a.inputCursor
+		for a.completionStart > 0 && a.inputText[a.completionStart-1] != ' ' {
+			a.completionStart--
+		}
+		word := string(a.inputText[a.completionStart:a.inputCursor])
+		if word == "" {
+			return
+		}
+		a.completion = a.server.CompleteNick(a.pane.buffer, word)
+		a.completionIndex = -1
+	}
+	if len(a.completion) == 0 {
+		return
+	}
+
+	a.completionIndex = (a.completionIndex + 1) % len(a.completion)
+	suffix := " "
+	if a.completionStart == 0 {
+		suffix = ": "
+	}
+	inserted := []rune(a.completion[a.completionIndex] + suffix)
+
+	rest := append(inserted, a.inputText[a.inputCursor:]...)
+	a.inputText = append(a.inputText[:a.completionStart], rest...)
+	a.inputCursor = a.completionStart + len(inserted)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"github.com/gdamore/tcell/v2"
+	"os/exec"
+	"ribbirc/utils"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const notificationLength = 200
+
+func (a *Application) notifyMessage(buffer string, log utils.Log) {
+	if a.muted[strings.ToLower(buffer)] || a.quietHours(time.Now()) {
+		return
+	}
+	if a.focused && a.bufferVisible(buffer) {
+		return
+	}
+
+	title := sanitizeNotification(fmt.Sprintf("%s in %s", log.Source, buffer))
+	body := sanitizeNotification(log.Text)
+
+	switch a.config.Notifications.Method {
+	case "bell":
+		a.screen.Beep()
+	case "osc9":
+		a.writeTerminal(fmt.Sprintf("\x1b]9;%s: %s\x07", title, body))
+	case "osc777":
+		title = strings.ReplaceAll(title, ";", ",")
+		a.writeTerminal(fmt.Sprintf("\x1b]777;notify;%s;%s\x07", title, body))
+	case "command":
+		a.runNotifyCommand(sanitizeNotification(buffer), sanitizeNotification(log.Source), body)
+	}
+}
+
+func (a *Application) writeTerminal(sequence string) {
+	if tty, ok := a.screen.Tty(); ok {
+		tty.Write([]byte(sequence))
+	}
+}
+
+func (a *Application) runNotifyCommand(buffer string, nick string, text string) {
+	command := a.config.Notifications.Command
+	if len(command) == 0 {
+		return
+	}
+
+	replacer := strings.NewReplacer(
+		"{buffer}", strings.TrimLeft(buffer, "-"),
+		"{nick}", strings.TrimLeft(nick, "-"),
+		"{text}", strings.TrimLeft(text, "-"),
+	)
+	args := make([]string, 0, len(command)-1)
+	for _, arg := range command[1:] {
+		args = append(args, replacer.Replace(arg))
+	}
+
+	cmd := exec.Command(command[0], args...)
+	if err := cmd.Start(); err != nil {
+		a.server.GetLogger().Append("System", utils.LogError, fmt.Sprintf("Notification command failed: %s", err))
+		return
+	}
+	go cmd.Wait()
+}
+
+func (a *Application) quietHours(now time.Time) bool {
+	quiet := a.config.Notifications.QuietHours
+	start, err := time.Parse("15:04", quiet.Start)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse("15:04", quiet.End)
+	if err != nil {
+		return false
+	}
+
+	minutes := now.Hour()*60 + now.Minute()
+	from := start.Hour()*60 + start.Minute()
+	to := end.Hour()*60 + end.Minute()
+	if from <= to {
+		return minutes >= from && minutes < to
+	}
+	return minutes >= from || minutes < to
+}
+
+func (a *Application) setMuted(buffer string, muted bool) {
+	if buffer == "" {
+		buffer = a.pane.buffer
+	}
+	a.muted[strings.ToLower(buffer)] = muted
+	if buffer == "" {
+		buffer = "Status"
+	}
+
+	state := "unmuted"
+	if muted {
+		state = "muted"
+	}
+	a.server.GetLogger().Append("System", utils.LogStatus, fmt.Sprintf("Notifications %s for %s", state, buffer))
+}
+
+func (a *Application) handleFocusEvent(ev *tcell.EventFocus) {
+	a.focused = ev.Focused
+}
+
+func sanitizeNotification(text string) string {
+	runes := make([]rune, 0, len(text))
+	for _, r := range text {
+		if unicode.IsControl(r) || r == unicode.ReplacementChar {
+			continue
+		}
+		runes = append(runes, r)
+		if len(runes) == notificationLength {
+			break
+		}
+	}
+	return strings.TrimSpace(string(runes))
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"github.com/gdamore/tcell/v2"
+	"regexp"
+	"ribbirc/utils"
+	"unicode"
+)
+
+type searchState struct {
+	text    []rune
+	pattern *regexp.Regexp
+	typing  bool
+	matches []int
+	current int
+}
+
+func (a *Application) bufferLogs(buffer string) *utils.Logger {
+	if buffer == "" {
+		return a.server.GetLogger()
+	}
+	channel, err := a.server.GetChannel(buffer)
+	if err != nil {
+		return nil
+	}
+	return channel.Logs
+}
+
+func (a *Application) startSearch(text string, typing bool) {
+	a.search = &searchState{text: []rune(text), typing: typing}
+	a.updateSearch()
+}
+
+func (a *Application) stopSearch() {
+	a.search = nil
+}
+
+func (a *Application) updateSearch() {
+	pattern, err := regexp.Compile("(?i)" + string(a.search.text))
+	if err != nil {
+		return
+	}
+	a.search.pattern = pattern
+	a.search.matches = nil
+	if logs := a.bufferLogs(a.pane.buffer); logs != nil && len(a.search.text) > 0 {
+		a.search.matches = logs.Search(pattern)
+	}
+	a.search.current = len(a.search.matches) - 1
+	a.jumpToMatch()
+}
+
+func (a *Application) searchStep(delta int) {
+	if len(a.search.matches) == 0 {
+		return
+	}
+	a.search.current = max(0, min(len(a.search.matches)-1, a.search.current+delta))
+	a.jumpToMatch()
+}
+
+func (a *Application) jumpToMatch() {
+	if a.search.current < 0 {
+		return
+	}
+	a.jumpToPosition(a.search.matches[a.search.current])
+}
+
+func (a *Application) jumpToPosition(position int) {
+	a.pane.offset = max(0, a.rowsBelow(a.pane, position)-a.paneViewport(a.pane).height/2)
+}
+
+func (a *Application) searchStyle(position int, log utils.Log, style tcell.Style) tcell.Style {
+	if a.search == nil || a.search.pattern == nil || len(a.search.text) == 0 {
+		return style
+	}
+	if a.search.current >= 0 && a.search.matches[a.search.current] == position {
+		return a.theme.apply(style, "search_current")
+	}
+	if a.search.pattern.MatchString(log.Text) || a.search.pattern.MatchString(log.Source) {
+		return a.theme.apply(style, "search_match")
+	}
+	return style
+}
+
+func (a *Application) handleSearchKey(ev *tcell.EventKey) bool {
+	if a.search == nil {
+		return false
+	}
+
+	switch ev.Key() {
+	case tcell.KeyEscape:
+		a.stopSearch()
+	case tcell.KeyEnter:
+		if a.search.typing {
+			a.search.typing = false
+		} else {
+			a.searchStep(-1)
+		}
+	case tcell.KeyCtrlN:
+		a.searchStep(-1)
+	case tcell.KeyCtrlP:
+		a.searchStep(1)
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if !a.search.typing || len(a.search.text) == 0 {
+			return false
+		}
+		a.search.text = a.search.text[:len(a.search.text)-1]
+		a.updateSearch()
+	case tcell.KeyRune:
+		if !a.search.typing || !unicode.IsPrint(ev.Rune()) {
+			return false
+		}
+		a.search.text = append(a.search.text, ev.Rune())
+		a.updateSearch()
+	default:
+		return false
+	}
+	return true
+}
+
+func (a *Application) drawSearchInput() bool {
+	if a.search == nil {
+		return false
+	}
+
+	style := a.theme.style("input")
+	count := len(a.search.matches)
+	text := fmt.Sprintf("search (%d/%d): %s", a.search.current+1, count, string(a.search.text))
+	if count == 0 {
+		text = fmt.Sprintf("search (no matches): %s", string(a.search.text))
+	}
+	a.drawString(0, a.height-1, text, style)
+
+	if a.search.typing {
+		a.screen.ShowCursor(len([]rune(text)), a.height-1)
+	} else {
+		a.screen.HideCursor()
+	}
+	return true
+}
+
+func (a *Application) searchAll(text string) {
+	pattern, err := regexp.Compile("(?i)" + text)
+	if err != nil {
+		a.server.GetLogger().Append("System", utils.LogError, fmt.Sprintf("Invalid search pattern: %s", err))
+		return
+	}
+
+	entries := make([]jumpEntry, 0)
+	for _, buffer := range append([]string{""}, a.server.ChannelNames()...) {
+		logs := a.bufferLogs(buffer)
+		if logs == nil {
+			continue
+		}
+		for _, position := range logs.Search(pattern) {
+			if log, ok := logs.At(position); ok {
+				entries = append(entries, jumpEntry{buffer, position, log})
+			}
+		}
+	}
+
+	title := fmt.Sprintf("Search results for %s", text)
+	a.openView(a.pane.buffer, newJumpView(title, entries, text))
+}
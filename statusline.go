@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"ribbirc/client"
+	"strings"
+	"time"
+)
+
+var statusPattern = regexp.MustCompile(`\{(\w+)\}`)
+
+var statusWidgets = map[string]func(a *Application, status client.Status) string{
+	"nick": func(a *Application, status client.Status) string {
+		return status.Nick
+	},
+	"modes": func(a *Application, status client.Status) string {
+		if status.Modes == "" {
+			return ""
+		}
+		return "+" + status.Modes
+	},
+	"network": func(a *Application, status client.Status) string {
+		return status.Network
+	},
+	"state": func(a *Application, status client.Status) string {
+		return status.State.String()
+	},
+	"lag": func(a *Application, status client.Status) string {
+		if status.State != client.StateConnected || status.Lag <= 0 {
+			return ""
+		}
+		if status.Lag < time.Second {
+			return fmt.Sprintf("lag %dms", status.Lag.Milliseconds())
+		}
+		return fmt.Sprintf("lag %.1fs", status.Lag.Seconds())
+	},
+	"queue": func(a *Application, status client.Status) string {
+		if status.Queue == 0 {
+			return ""
+		}
+		return fmt.Sprintf("queue %d", status.Queue)
+	},
+	"away": func(a *Application, status client.Status) string {
+		away, message := a.server.Away()
+		if !away {
+			return ""
+		}
+		if message == "" {
+			return "away"
+		}
+		return fmt.Sprintf("away: %s", message)
+	},
+	"clock": func(a *Application, status client.Status) string {
+		return time.Now().Format("15:04")
+	},
+}
+
+func (a *Application) statusLine() string {
+	status := a.server.Status()
+	text := statusPattern.ReplaceAllStringFunc(a.config.StatusFormat, func(token string) string {
+		widget, ok := statusWidgets[token[1:len(token)-1]]
+		if !ok {
+			return token
+		}
+		return widget(a, status)
+	})
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func (a *Application) tickClock() {
+	for {
+		now := time.Now()
+		time.Sleep(now.Truncate(time.Minute).Add(time.Minute).Sub(now))
+		ev := &serverEvent{}
+		ev.SetEventNow()
+		a.screen.PostEvent(ev)
+	}
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gdamore/tcell/v2"
+	"os"
+	"path/filepath"
+	"ribbirc/config"
+	"ribbirc/utils"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var themeElements = []string{
+	"default", "bar", "tab_active", "activity_events", "activity_messages", "activity_highlight",
+	"message", "highlight", "system", "error", "status", "joined", "left", "nick_change", "marker",
+	"nicklist", "nicklist_away", "input", "view", "view_header", "view_selected",
+	"search_match", "search_current", "url",
+}
+
+var logElements = map[utils.LogKind]string{
+	utils.LogPrivMsg: "message",
+	utils.LogSystem:  "system",
+	utils.LogError:   "error",
+	utils.LogStatus:  "status",
+	utils.LogJoined:  "joined",
+	utils.LogLeft:    "left",
+	utils.LogMarker:  "marker",
+	utils.LogNick:    "nick_change",
+}
+
+var builtinThemes = map[string]string{
+	"dark": `{
+  "background": "black",
+  "styles": {
+    "default": {"fg": "reset", "bg": "reset"},
+    "bar": {"fg": "black", "bg": "white"},
+    "tab_active": {"bold": true},
+    "activity_events": {"fg": "gray"},
+    "activity_messages": {"fg": "navy", "bold": true},
+    "activity_highlight": {"fg": "maroon", "bold": true},
+    "highlight": {"fg": ["#ffd75f", "221", "yellow"], "bold": true},
+    "system": {"fg": ["#5f87d7", "68", "blue"]},
+    "error": {"fg": "red"},
+    "joined": {"fg": "green"},
+    "left": {"fg": "red"},
+    "nick_change": {"fg": ["#5f87d7", "68", "blue"]},
+    "marker": {"fg": "gray"},
+    "nicklist_away": {"fg": "gray"},
+    "view_header": {"fg": ["#5f87d7", "68", "blue"]},
+    "view_selected": {"reverse": true},
+    "search_match": {"underline": true},
+    "search_current": {"reverse": true},
+    "url": {"underline": true}
+  }
+}`,
+	"light": `{
+  "background": "white",
+  "styles": {
+    "default": {"fg": "black", "bg": "white"},
+    "bar": {"fg": "white", "bg": ["#005f87", "24", "navy"]},
+    "tab_active": {"bold": true, "underline": true},
+    "activity_events": {"fg": "silver"},
+    "activity_messages": {"fg": "yellow", "bold": true},
+    "activity_highlight": {"fg": "fuchsia", "bold": true},
+    "highlight": {"fg": ["#af5f00", "130", "olive"], "bold": true},
+    "system": {"fg": "navy"},
+    "error": {"fg": "maroon"},
+    "joined": {"fg": "green"},
+    "left": {"fg": "maroon"},
+    "nick_change": {"fg": "navy"},
+    "marker": {"fg": "gray"},
+    "nicklist_away": {"fg": "gray"},
+    "view_header": {"fg": "navy"},
+    "view_selected": {"reverse": true},
+    "search_match": {"underline": true},
+    "search_current": {"reverse": true},
+    "url": {"underline": true}
+  }
+}`,
+}
+
+type colorSpec []string
+
+func (c *colorSpec) UnmarshalJSON(data []byte) error {
+	var single string
+	if json.Unmarshal(data, &single) == nil {
+		*c = colorSpec{single}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(c))
+}
+
+type themeStyle struct {
+	Foreground colorSpec `json:"fg"`
+	Background colorSpec `json:"bg"`
+	Bold       bool      `json:"bold"`
+	Dim        bool      `json:"dim"`
+	Italic     bool      `json:"italic"`
+	Underline  bool      `json:"underline"`
+	Reverse    bool      `json:"reverse"`
+}
+
+type themeFile struct {
+	Background colorSpec             `json:"background"`
+	Styles     map[string]themeStyle `json:"styles"`
+}
+
+type themeEntry struct {
+	foreground tcell.Color
+	background tcell.Color
+	attrs      tcell.AttrMask
+}
+
+type theme struct {
+	name       string
+	background tcell.Color
+	entries    map[string]themeEntry
+}
+
+func loadTheme(name string, colors int) (*theme, error) {
+	file, err := readThemeFile(builtinThemes["dark"])
+	if err != nil {
+		return nil, err
+	}
+
+	if name != "dark" {
+		data, ok := builtinThemes[name]
+		if !ok {
+			dir, err := config.Dir()
+			if err != nil {
+				return nil, err
+			}
+			contents, err := os.ReadFile(filepath.Join(dir, "themes", name+".json"))
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, fmt.Errorf("unknown theme %s", name)
+			}
+			if err != nil {
+				return nil, err
+			}
+			data = string(contents)
+		}
+
+		custom, err := readThemeFile(data)
+		if err != nil {
+			return nil, fmt.Errorf("theme %s: %w", name, err)
+		}
+		if len(custom.Background) > 0 {
+			file.Background = custom.Background
+		}
+		for element, style := range custom.Styles {
+			file.Styles[element] = style
+		}
+	}
+
+	t := &theme{
+		name:       name,
+		background: resolveColor(file.Background, colors),
+		entries:    make(map[string]themeEntry),
+	}
+	for element, style := range file.Styles {
+		t.entries[element] = themeEntry{
+			foreground: resolveColor(style.Foreground, colors),
+			background: resolveColor(style.Background, colors),
+			attrs:      style.attrs(),
+		}
+	}
+	return t, nil
+}
+
+func readThemeFile(data string) (*themeFile, error) {
+	file := &themeFile{}
+	err := json.Unmarshal([]byte(data), file)
+	if err != nil {
+		return nil, err
+	}
+	for element := range file.Styles {
+		if !contains(themeElements, element) {
+			return nil, fmt.Errorf("unknown element %s", element)
+		}
+	}
+	if file.Styles == nil {
+		file.Styles = make(map[string]themeStyle)
+	}
+	return file, nil
+}
+
+func (s themeStyle) attrs() tcell.AttrMask {
+	attrs := tcell.AttrNone
+	if s.Bold {
+		attrs |= tcell.AttrBold
+	}
+	if s.Dim {
+		attrs |= tcell.AttrDim
+	}
+	if s.Italic {
+		attrs |= tcell.AttrItalic
+	}
+	if s.Underline {
+		attrs |= tcell.AttrUnderline
+	}
+	if s.Reverse {
+		attrs |= tcell.AttrReverse
+	}
+	return attrs
+}
+
+func resolveColor(spec colorSpec, colors int) tcell.Color {
+	resolved := tcell.ColorDefault
+	for _, value := range spec {
+		color, ok := parseColor(value)
+		if !ok {
+			continue
+		}
+		resolved = color
+		if color == tcell.ColorReset || (color.IsRGB() && colors >= 1<<24) || (!color.IsRGB() && int(color-tcell.ColorValid) < colors) {
+			return color
+		}
+	}
+	return resolved
+}
+
+func parseColor(value string) (tcell.Color, bool) {
+	switch strings.ToLower(value) {
+	case "reset":
+		return tcell.ColorReset, true
+	case "default", "":
+		return tcell.ColorDefault, false
+	}
+	if index, err := strconv.Atoi(value); err == nil && index >= 0 && index < 256 {
+		return tcell.PaletteColor(index), true
+	}
+	color := tcell.GetColor(strings.ToLower(value))
+	return color, color != tcell.ColorDefault
+}
+
+func (t *theme) apply(style tcell.Style, element string) tcell.Style {
+	entry, ok := t.entries[element]
+	if !ok {
+		return style
+	}
+	if entry.foreground != tcell.ColorDefault {
+		style = style.Foreground(entry.foreground)
+	}
+	if entry.background != tcell.ColorDefault {
+		style = style.Background(entry.background)
+	}
+	_, _, attrs := style.Decompose()
+	return style.Attributes(attrs | entry.attrs)
+}
+
+func (t *theme) style(element string) tcell.Style {
+	return t.apply(t.apply(tcell.StyleDefault, "default"), element)
+}
+
+func (t *theme) logStyle(style tcell.Style, log utils.Log) tcell.Style {
+	if log.Kind == utils.LogPrivMsg && log.Highlight {
+		return t.apply(style, "highlight")
+	}
+	return t.apply(style, logElements[log.Kind])
+}
+
+func themeNames() []string {
+	names := make([]string, 0, len(builtinThemes))
+	for name := range builtinThemes {
+		names = append(names, name)
+	}
+	if dir, err := config.Dir(); err == nil {
+		files, _ := filepath.Glob(filepath.Join(dir, "themes", "*.json"))
+		for _, file := range files {
+			names = append(names, strings.TrimSuffix(filepath.Base(file), ".json"))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (a *Application) setTheme(name string) error {
+	t, err := loadTheme(name, a.screen.Colors())
+	if err != nil {
+		return err
+	}
+	a.theme = t
+	a.initNickColors()
+	return nil
+}
+
+func (a *Application) themeCommand(name string) {
+	logs := a.server.GetLogger()
+	if name == "" {
+		text := fmt.Sprintf("Current theme: %s. Available themes: %s", a.theme.name, strings.Join(themeNames(), ", "))
+		logs.Append("System", utils.LogStatus, text)
+		return
+	}
+	if err := a.setTheme(name); err != nil {
+		logs.Append("System", utils.LogError, fmt.Sprintf("Could not load theme: %s", err))
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
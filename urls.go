@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"github.com/gdamore/tcell/v2"
+	"net/url"
+	"os/exec"
+	"regexp"
+	"ribbirc/utils"
+	"runtime"
+	"strings"
+	"unicode"
+)
+
+var urlPattern = regexp.MustCompile(`\b[hH][tT][tT][pP][sS]?://[^\s<>"\p{Cc}\p{Cf}]+`)
+
+func findURLs(text string) [][2]int {
+	spans := make([][2]int, 0)
+	for _, match := range urlPattern.FindAllStringIndex(text, -1) {
+		end := match[0] + len(trimURL(text[match[0]:match[1]]))
+		spans = append(spans, [2]int{match[0], end})
+	}
+	return spans
+}
+
+func trimURL(link string) string {
+	for len(link) > 0 {
+		last := link[len(link)-1]
+		switch {
+		case strings.IndexByte(".,;:!?'\"]}", last) >= 0:
+		case last == ')' && strings.Count(link, "(") < strings.Count(link, ")"):
+		default:
+			return link
+		}
+		link = link[:len(link)-1]
+	}
+	return link
+}
+
+func (a *Application) urlSpans(text string, style tcell.Style) []styleSpan {
+	spans := make([]styleSpan, 0)
+	for _, match := range findURLs(text) {
+		spans = append(spans, styleSpan{start: match[0], end: match[1], style: a.theme.apply(style, "url"), url: text[match[0]:match[1]]})
+	}
+	return spans
+}
+
+func validateURL(link string) error {
+	for _, r := range link {
+		if unicode.IsControl(r) || unicode.Is(unicode.Cf, r) {
+			return fmt.Errorf("URL contains control or formatting characters")
+		}
+	}
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return err
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("unsupported URL scheme %s", parsed.Scheme)
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("URL has no host")
+	}
+	if parsed.User != nil {
+		return fmt.Errorf("URL contains credentials, the real host is %s", parsed.Hostname())
+	}
+	return nil
+}
+
+func defaultOpener() []string {
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{"open", "{url}"}
+	case "windows":
+		return []string{"rundll32", "url.dll,FileProtocolHandler", "{url}"}
+	}
+	return []string{"xdg-open", "{url}"}
+}
+
+func (a *Application) openURL(link string) {
+	logs := a.server.GetLogger()
+	if err := validateURL(link); err != nil {
+		logs.Append("System", utils.LogError, fmt.Sprintf("Not opening %q: %s", link, err))
+		return
+	}
+
+	command := a.config.URLOpener
+	if len(command) == 0 {
+		command = defaultOpener()
+	}
+	args := make([]string, 0, len(command))
+	substituted := false
+	for _, arg := range command[1:] {
+		if strings.Contains(arg, "{url}") {
+			arg = strings.ReplaceAll(arg, "{url}", link)
+			substituted = true
+		}
+		args = append(args, arg)
+	}
+	if !substituted {
+		args = append(args, link)
+	}
+
+	cmd := exec.Command(command[0], args...)
+	if err := cmd.Start(); err != nil {
+		logs.Append("System", utils.LogError, fmt.Sprintf("URL opener failed: %s", err))
+		return
+	}
+	logs.Append("System", utils.LogStatus, fmt.Sprintf("Opening %s", link))
+	go cmd.Wait()
+}
@@ -0,0 +1,207 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const archiveTimeFormat = "2006-01-02 15:04:05"
+
+type Archive struct {
+	mutex   sync.Mutex
+	root    string
+	network func() string
+	buffer  string
+	joins   bool
+
+	dir  string
+	day  string
+	file *os.File
+}
+
+func NewArchive(root string, network func() string, buffer string, joins bool) *Archive {
+	return &Archive{
+		root:    root,
+		network: network,
+		buffer:  buffer,
+		joins:   joins,
+	}
+}
+
+func (a *Archive) Dir() string {
+	return filepath.Join(a.root, SafeFileName(a.network()), SafeFileName(a.buffer))
+}
+
+func (a *Archive) Write(log Log) error {
+	if !a.joins && (log.Kind == LogJoined || log.Kind == LogLeft) {
+		return nil
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	day := log.Time.Format(time.DateOnly)
+	if a.file == nil || a.day != day || a.dir != a.Dir() {
+		err := a.open(day)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := a.file.WriteString(FormatLogLine(log) + "\n")
+	return err
+}
+
+func (a *Archive) open(day string) error {
+	if a.file != nil {
+		a.file.Close()
+		a.file = nil
+	}
+
+	dir := a.Dir()
+	err := os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, day+".log"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	a.dir = dir
+	a.day = day
+	a.file = file
+	return nil
+}
+
+func (a *Archive) Close() error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.file == nil {
+		return nil
+	}
+	err := a.file.Close()
+	a.file = nil
+	return err
+}
+
+func (a *Archive) Tail(count int) ([]Log, error) {
+	files, err := filepath.Glob(filepath.Join(a.Dir(), "*.log"))
+	if err != nil || len(files) == 0 {
+		return nil, err
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(files)))
+
+	logs := make([]Log, 0)
+	for _, file := range files {
+		if len(logs) >= count {
+			break
+		}
+		dayLogs, err := readLogFile(file)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(dayLogs, logs...)
+	}
+
+	if len(logs) > count {
+		logs = logs[len(logs)-count:]
+	}
+	return logs, nil
+}
+
+func readLogFile(path string) ([]Log, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	logs := make([]Log, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if log, ok := ParseLogLine(scanner.Text()); ok {
+			logs = append(logs, log)
+		}
+	}
+	return logs, scanner.Err()
+}
+
+func FormatLogLine(log Log) string {
+	prefix := "--"
+	text := log.Text
+	switch log.Kind {
+	case LogPrivMsg:
+		prefix = log.Source
+	case LogJoined:
+		prefix = "-->"
+		text = fmt.Sprintf("%s %s", log.Source, log.Text)
+	case LogLeft:
+		prefix = "<--"
+		text = fmt.Sprintf("%s %s", log.Source, log.Text)
+	case LogError:
+		prefix = "=!="
+	}
+	text = strings.NewReplacer("\r", " ", "\n", " ").Replace(text)
+	return fmt.Sprintf("%s\t%s\t%s", log.Time.Format(archiveTimeFormat), prefix, text)
+}
+
+func ParseLogLine(line string) (Log, bool) {
+	parts := strings.SplitN(line, "\t", 3)
+	if len(parts) != 3 {
+		return Log{}, false
+	}
+	at, err := time.ParseInLocation(archiveTimeFormat, parts[0], time.Local)
+	if err != nil {
+		return Log{}, false
+	}
+
+	log := Log{Time: at, Text: parts[2]}
+	switch parts[1] {
+	case "-->", "<--":
+		log.Kind = LogJoined
+		if parts[1] == "<--" {
+			log.Kind = LogLeft
+		}
+		log.Source, log.Text, _ = strings.Cut(parts[2], " ")
+	case "--":
+		log.Kind = LogSystem
+		log.Source = "*"
+	case "=!=":
+		log.Kind = LogError
+		log.Source = "System"
+	default:
+		log.Kind = LogPrivMsg
+		log.Source = parts[1]
+	}
+	return log, true
+}
+
+func SafeFileName(name string) string {
+	var builder strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c >= 'A' && c <= 'Z':
+			builder.WriteByte(c + 'a' - 'A')
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '#' || c == '&' || c == '+' || c == '!' || c == '-' || c == '_',
+			c == '.' && i > 0:
+			builder.WriteByte(c)
+		default:
+			fmt.Fprintf(&builder, "%%%02X", c)
+		}
+	}
+	if builder.Len() == 0 {
+		return "_"
+	}
+	return builder.String()
+}
@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSafeFileName(t *testing.T) {
+	tests := map[string]struct {
+		input  string
+		output string
+	}{
+		"Channel": {
+			input:  "#RibbIRC",
+			output: "#ribbirc",
+		},
+		"Separators": {
+			input:  "#a/b\\c",
+			output: "#a%2Fb%5Cc",
+		},
+		"DotDot": {
+			input:  "..",
+			output: "%2E.",
+		},
+		"Control": {
+			input:  "#a\x00b c",
+			output: "#a%00b%20c",
+		},
+		"Empty": {
+			input:  "",
+			output: "_",
+		},
+	}
+
+	fails := 0
+	for testName, test := range tests {
+		t.Logf("Running test %s...", testName)
+
+		output := SafeFileName(test.input)
+		if output == test.output {
+			t.Logf("  PASS")
+		} else {
+			t.Logf("  FAIL: Expected '%s', got '%s'", test.output, output)
+			fails++
+		}
+	}
+
+	if fails > 0 {
+		t.Fatalf("Failed %d/%d tests", fails, len(tests))
+	}
+}
+
+func TestFormatLogLine(t *testing.T) {
+	at := time.Date(2024, 3, 1, 9, 5, 7, 0, time.Local)
+	tests := map[string]struct {
+		input  Log
+		output string
+	}{
+		"PrivMsg": {
+			input:  Log{Time: at, Source: "alice", Kind: LogPrivMsg, Text: "hello there"},
+			output: "2024-03-01 09:05:07\talice\thello there",
+		},
+		"Joined": {
+			input:  Log{Time: at, Source: "bob", Kind: LogJoined, Text: "joined."},
+			output: "2024-03-01 09:05:07\t-->\tbob joined.",
+		},
+		"Left": {
+			input:  Log{Time: at, Source: "bob", Kind: LogLeft, Text: "left. <bye>"},
+			output: "2024-03-01 09:05:07\t<--\tbob left. <bye>",
+		},
+		"Error": {
+			input:  Log{Time: at, Source: "System", Kind: LogError, Text: "oops\r\nagain"},
+			output: "2024-03-01 09:05:07\t=!=\toops  again",
+		},
+	}
+
+	fails := 0
+	for testName, test := range tests {
+		t.Logf("Running test %s...", testName)
+
+		output := FormatLogLine(test.input)
+		if output == test.output {
+			t.Logf("  PASS")
+		} else {
+			t.Logf("  FAIL: Expected '%s', got '%s'", test.output, output)
+			fails++
+		}
+	}
+
+	if fails > 0 {
+		t.Fatalf("Failed %d/%d tests", fails, len(tests))
+	}
+}
+
+func TestParseLogLine(t *testing.T) {
+	at := time.Date(2024, 3, 1, 9, 5, 7, 0, time.Local)
+	tests := map[string]struct {
+		input  Log
+		output Log
+	}{
+		"PrivMsg": {
+			input:  Log{Time: at, Source: "alice", Kind: LogPrivMsg, Text: "hello\tthere"},
+			output: Log{Time: at, Source: "alice", Kind: LogPrivMsg, Text: "hello\tthere"},
+		},
+		"Left": {
+			input:  Log{Time: at, Source: "bob", Kind: LogLeft, Text: "left. <bye>"},
+			output: Log{Time: at, Source: "bob", Kind: LogLeft, Text: "left. <bye>"},
+		},
+		"Status": {
+			input:  Log{Time: at, Source: "irc.example.net", Kind: LogStatus, Text: "Connected"},
+			output: Log{Time: at, Source: "*", Kind: LogSystem, Text: "Connected"},
+		},
+	}
+
+	fails := 0
+	for testName, test := range tests {
+		t.Logf("Running test %s...", testName)
+
+		output, ok := ParseLogLine(FormatLogLine(test.input))
+		if ok && output == test.output {
+			t.Logf("  PASS")
+		} else {
+			t.Logf("  FAIL: Expected '%v', got '%v'", test.output, output)
+			fails++
+		}
+	}
+
+	if fails > 0 {
+		t.Fatalf("Failed %d/%d tests", fails, len(tests))
+	}
+}
+
+func TestArchiveNetworkChange(t *testing.T) {
+	root := t.TempDir()
+	network := "irc.example.net"
+	archive := NewArchive(root, func() string { return network }, "server", true)
+	defer archive.Close()
+
+	now := time.Now()
+	if err := archive.Write(Log{Time: now, Source: "System", Kind: LogStatus, Text: "connecting"}); err != nil {
+		t.Fatal(err)
+	}
+	network = "example"
+	if err := archive.Write(Log{Time: now, Source: "System", Kind: LogStatus, Text: "welcome"}); err != nil {
+		t.Fatal(err)
+	}
+
+	day := now.Format(time.DateOnly) + ".log"
+	for _, dir := range []string{"irc.example.net", "example"} {
+		if _, err := os.Stat(filepath.Join(root, dir, "server", day)); err != nil {
+			t.Fatalf("Expected a log file under %s: %s", dir, err)
+		}
+	}
+}
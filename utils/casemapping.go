@@ -0,0 +1,55 @@
+package utils
+
+import "strings"
+
+var rfc1459Folder = strings.NewReplacer("[", "{", "]", "}", "\\", "|", "~", "^")
+var strictRfc1459Folder = strings.NewReplacer("[", "{", "]", "}", "\\", "|")
+
+func Casefold(mapping string, text string) string {
+	lower := strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' {
+			return r + 'a' - 'A'
+		}
+		return r
+	}, text)
+
+	switch mapping {
+	case "ascii":
+		return lower
+	case "rfc1459-strict":
+		return strictRfc1459Folder.Replace(lower)
+	case "rfc7613":
+		return strings.ToLower(text)
+	default:
+		return rfc1459Folder.Replace(lower)
+	}
+}
+
+func MatchMask(mapping string, pattern string, text string) bool {
+	return matchWildcard([]rune(Casefold(mapping, pattern)), []rune(Casefold(mapping, text)))
+}
+
+func matchWildcard(pattern []rune, text []rune) bool {
+	star, mark := -1, 0
+	p, t := 0, 0
+	for t < len(text) {
+		switch {
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == text[t]):
+			p++
+			t++
+		case p < len(pattern) && pattern[p] == '*':
+			star, mark = p, t
+			p++
+		case star >= 0:
+			p = star + 1
+			mark++
+			t = mark
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
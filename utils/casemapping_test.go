@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestMatchMask(t *testing.T) {
+	tests := map[string]struct {
+		pattern string
+		text    string
+		output  bool
+	}{
+		"Exact": {
+			pattern: "nick!user@host",
+			text:    "nick!user@host",
+			output:  true,
+		},
+		"Star": {
+			pattern: "*!*@*.example.com",
+			text:    "spam!bot@a.b.example.com",
+			output:  true,
+		},
+		"Question": {
+			pattern: "bot??!*@*",
+			text:    "bot42!x@y",
+			output:  true,
+		},
+		"Casemapping": {
+			pattern: "[Nick]!*@*",
+			text:    "{nick}!user@host",
+			output:  true,
+		},
+		"Brackets": {
+			pattern: "a[b]!*@*",
+			text:    "ab!user@host",
+			output:  false,
+		},
+		"Mismatch": {
+			pattern: "*!*@host",
+			text:    "nick!user@other",
+			output:  false,
+		},
+	}
+
+	fails := 0
+	for testName, test := range tests {
+		t.Logf("Running test %s...", testName)
+
+		output := MatchMask("rfc1459", test.pattern, test.text)
+		if output == test.output {
+			t.Logf("  PASS")
+		} else {
+			t.Logf("  FAIL: Expected '%v', got '%v'", test.output, output)
+			fails++
+		}
+	}
+
+	if fails > 0 {
+		t.Fatalf("Failed %d/%d tests", fails, len(tests))
+	}
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"strings"
+)
+
+const formattingChars = "\x02\x03\x04\x0f\x11\x16\x1d\x1e\x1f"
+
+func StripFormatting(text string) string {
+	if !strings.ContainsAny(text, formattingChars) {
+		return text
+	}
+
+	var builder strings.Builder
+	builder.Grow(len(text))
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\x02', '\x0f', '\x11', '\x16', '\x1d', '\x1e', '\x1f':
+		case '\x03':
+			i = skipColor(text, i+1, 2, isDigit) - 1
+		case '\x04':
+			i = skipColor(text, i+1, 6, isHexDigit) - 1
+		default:
+			builder.WriteByte(text[i])
+		}
+	}
+	return builder.String()
+}
+
+func skipColor(text string, i int, length int, valid func(byte) bool) int {
+	end := skipRun(text, i, length, valid)
+	if end == i {
+		return i
+	}
+	if end+1 < len(text) && text[end] == ',' && valid(text[end+1]) {
+		return skipRun(text, end+1, length, valid)
+	}
+	return end
+}
+
+func skipRun(text string, i int, length int, valid func(byte) bool) int {
+	end := i
+	for end < len(text) && end-i < length && valid(text[end]) {
+		end++
+	}
+	return end
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestStripFormatting(t *testing.T) {
+	tests := map[string]struct {
+		input  string
+		output string
+	}{
+		"Plain": {
+			input:  "see https://example.com",
+			output: "see https://example.com",
+		},
+		"Bold": {
+			input:  "\x02bold\x02 text",
+			output: "bold text",
+		},
+		"Color": {
+			input:  "\x0304red\x03 and \x0312,01blue",
+			output: "red and blue",
+		},
+		"ColorDigits": {
+			input:  "\x03041234",
+			output: "1234",
+		},
+		"ColorComma": {
+			input:  "\x034,text",
+			output: ",text",
+		},
+		"HexColor": {
+			input:  "\x04ff0000,00ff00green",
+			output: "green",
+		},
+		"Reset": {
+			input:  "\x1funder\x0f\x1ditalic\x1e\x11\x16",
+			output: "underitalic",
+		},
+		"URL": {
+			input:  "\x0302https://example.com\x0f.",
+			output: "https://example.com.",
+		},
+	}
+
+	fails := 0
+	for testName, test := range tests {
+		t.Logf("Running test %s...", testName)
+
+		output := StripFormatting(test.input)
+		if output == test.output {
+			t.Logf("  PASS")
+		} else {
+			t.Logf("  FAIL: Expected %q, got %q", test.output, output)
+			fails++
+		}
+	}
+
+	if fails > 0 {
+		t.Fatalf("Failed %d/%d tests", fails, len(tests))
+	}
+}
@@ -1,6 +1,10 @@
 package utils
 
-import "sync"
+import (
+	"regexp"
+	"sync"
+	"time"
+)
 
 type LogKind int
 
@@ -11,53 +15,189 @@ const (
 	LogStatus
 	LogJoined
 	LogLeft
+	LogMarker
+	LogNick
 )
 
 type Log struct {
-	Source string
-	Kind   LogKind
-	Text   string
+	Time      time.Time
+	Source    string
+	Kind      LogKind
+	Text      string
+	Highlight bool
+	Filtered  bool
 }
 
 type Logger struct {
-	mutex  sync.Mutex
-	logs   []Log
-	length int
+	mutex    sync.Mutex
+	logs     []Log
+	capacity int
+	start    int
+	length   int
+	total    int
+	archive  *Archive
+	failed   bool
+	onError  func(error)
+	listener func(Log)
 }
 
-func NewLogger() *Logger {
+func NewLogger(capacity int) *Logger {
+	capacity = max(capacity, 1)
 	return &Logger{
-		logs: make([]Log, 0),
+		logs:     make([]Log, 0, min(capacity, 64)),
+		capacity: capacity,
 	}
 }
 
 func (l *Logger) Append(source string, kind LogKind, text string) {
+	l.AppendLog(Log{Time: time.Now(), Source: source, Kind: kind, Text: text})
+}
+
+func (l *Logger) AppendLog(log Log) int {
+	l.mutex.Lock()
+	l.push(log)
+	var err error
+	if l.archive != nil {
+		err = l.archive.Write(log)
+	}
+	report := err != nil && !l.failed && l.onError != nil
+	l.failed = l.failed || err != nil
+	position, listener, onError := l.total-1, l.listener, l.onError
+	l.mutex.Unlock()
+
+	if report {
+		onError(err)
+	}
+	if listener != nil {
+		listener(log)
+	}
+	return position
+}
+
+func (l *Logger) push(log Log) {
+	if len(l.logs) < l.capacity {
+		l.logs = append(l.logs, log)
+		l.length++
+	} else {
+		l.logs[l.start] = log
+		l.start = (l.start + 1) % l.capacity
+	}
+	l.total++
+}
+
+func (l *Logger) Restore(logs []Log) {
+	if len(logs) == 0 {
+		return
+	}
+
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
 
-	l.logs = append(l.logs, Log{source, kind, text})
-	l.length++
+	for _, log := range logs {
+		l.push(log)
+	}
+	l.push(Log{Time: time.Now(), Source: "*", Kind: LogMarker, Text: "restored history"})
 }
 
-func (l *Logger) GetNLogs(height int, offset int) []Log {
+func (l *Logger) SetArchive(archive *Archive, onError func(error)) {
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
 
-	start := l.length - height - offset
-	end := l.length - offset
+	l.archive = archive
+	l.failed = false
+	l.onError = onError
+}
+
+func (l *Logger) SetListener(listener func(Log)) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.listener = listener
+}
+
+func (l *Logger) Close() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.archive == nil {
+		return nil
+	}
+	return l.archive.Close()
+}
+
+func (l *Logger) Clear() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.logs = l.logs[:0]
+	l.start = 0
+	l.length = 0
+}
 
-	logs := make([]Log, 0)
+func (l *Logger) Len() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.length
+}
+
+func (l *Logger) Bounds() (int, int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.total - l.length, l.total
+}
+
+func (l *Logger) At(position int) (Log, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	index := position - (l.total - l.length)
+	if index < 0 || index >= l.length {
+		return Log{}, false
+	}
+	return l.at(index), true
+}
+
+func (l *Logger) at(index int) Log {
+	return l.logs[(l.start+index)%len(l.logs)]
+}
+
+func (l *Logger) GetNLogs(dst []Log, height int, offset int) []Log {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	dst = dst[:0]
+	start := max(l.length-height-offset, 0)
+	end := l.length - offset
 	for i := start; i < end; i++ {
-		if i >= 0 {
-			logs = append(logs, l.logs[i])
-		}
+		dst = append(dst, l.at(i))
 	}
-	return logs
+	return dst
 }
 
 func (l *Logger) GetAllLogs() []Log {
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
 
-	return append([]Log{}, l.logs...)
+	logs := make([]Log, 0, l.length)
+	for i := 0; i < l.length; i++ {
+		logs = append(logs, l.at(i))
+	}
+	return logs
+}
+
+func (l *Logger) Search(pattern *regexp.Regexp) []int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	positions := make([]int, 0)
+	first := l.total - l.length
+	for i := 0; i < l.length; i++ {
+		log := l.at(i)
+		if pattern.MatchString(log.Text) || pattern.MatchString(log.Source) {
+			positions = append(positions, first+i)
+		}
+	}
+	return positions
 }
@@ -0,0 +1,168 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestLoggerGetNLogs(t *testing.T) {
+	tests := map[string]struct {
+		capacity   int
+		appends    int
+		clearAfter int
+		height     int
+		offset     int
+		output     []string
+	}{
+		"Partial": {
+			capacity: 10,
+			appends:  3,
+			height:   5,
+			offset:   0,
+			output:   []string{"0", "1", "2"},
+		},
+		"Wrapped": {
+			capacity: 4,
+			appends:  10,
+			height:   3,
+			offset:   0,
+			output:   []string{"7", "8", "9"},
+		},
+		"WrappedOffset": {
+			capacity: 4,
+			appends:  10,
+			height:   3,
+			offset:   2,
+			output:   []string{"6", "7"},
+		},
+		"OffsetPastEnd": {
+			capacity: 4,
+			appends:  10,
+			height:   3,
+			offset:   6,
+			output:   []string{},
+		},
+		"NegativeCapacity": {
+			capacity: -5,
+			appends:  3,
+			height:   5,
+			offset:   0,
+			output:   []string{"2"},
+		},
+		"ClearedWrapped": {
+			capacity:   4,
+			appends:    10,
+			clearAfter: 7,
+			height:     5,
+			offset:     0,
+			output:     []string{"7", "8", "9"},
+		},
+	}
+
+	fails := 0
+	for testName, test := range tests {
+		t.Logf("Running test %s...", testName)
+
+		logger := NewLogger(test.capacity)
+		for i := range test.appends {
+			if test.clearAfter > 0 && i == test.clearAfter {
+				logger.Clear()
+			}
+			logger.Append("src", LogPrivMsg, fmt.Sprint(i))
+		}
+		logs := logger.GetNLogs(nil, test.height, test.offset)
+
+		output := make([]string, 0)
+		for _, log := range logs {
+			output = append(output, log.Text)
+		}
+		if fmt.Sprint(output) == fmt.Sprint(test.output) && logger.Len() <= max(test.capacity, 1) {
+			t.Logf("  PASS")
+		} else {
+			t.Logf("  FAIL: Expected %v, got %v", test.output, output)
+			fails++
+		}
+	}
+
+	if fails > 0 {
+		t.Fatalf("Failed %d/%d tests", fails, len(tests))
+	}
+}
+
+func TestLoggerGetNLogsAllocs(t *testing.T) {
+	logger := NewLogger(100)
+	for range 250 {
+		logger.Append("src", LogPrivMsg, "text")
+	}
+
+	buffer := make([]Log, 0, 50)
+	allocs := testing.AllocsPerRun(100, func() {
+		buffer = logger.GetNLogs(buffer, 50, 10)
+	})
+	if allocs != 0 {
+		t.Fatalf("Expected no allocations, got %.1f", allocs)
+	}
+}
+
+func TestLoggerArchiveError(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(root, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	errors := 0
+	logger := NewLogger(10)
+	logger.SetArchive(NewArchive(root, func() string { return "net" }, "#chan", true), func(error) {
+		errors++
+	})
+	for range 3 {
+		logger.Append("src", LogPrivMsg, "text")
+	}
+	if errors != 1 || logger.Len() != 3 {
+		t.Fatalf("Expected 1 reported error and 3 logs, got %d errors and %d logs", errors, logger.Len())
+	}
+}
+
+func BenchmarkLoggerAppend(b *testing.B) {
+	logger := NewLogger(5000)
+	b.ReportAllocs()
+	for range b.N {
+		logger.Append("src", LogPrivMsg, "text")
+	}
+}
+
+func BenchmarkLoggerGetNLogs(b *testing.B) {
+	logger := NewLogger(5000)
+	for range 10000 {
+		logger.Append("src", LogPrivMsg, "text")
+	}
+
+	buffer := make([]Log, 0, 60)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := range b.N {
+		buffer = logger.GetNLogs(buffer, 60, i%1000)
+	}
+}
+
+func BenchmarkLoggerMemory(b *testing.B) {
+	var stats runtime.MemStats
+	for range b.N {
+		logger := NewLogger(5000)
+		for i := range 2_000_000 {
+			logger.Append("src", LogPrivMsg, "text")
+			if i == 100_000 {
+				runtime.GC()
+				runtime.ReadMemStats(&stats)
+				b.ReportMetric(float64(stats.HeapAlloc), "heap@100k")
+			}
+		}
+		runtime.GC()
+		runtime.ReadMemStats(&stats)
+		b.ReportMetric(float64(stats.HeapAlloc), "heap@2M")
+		runtime.KeepAlive(logger)
+	}
+}
@@ -20,6 +20,30 @@ func (m *Message) ParamNick(index int) string {
 	return nickFromHost(m.Parameters[index])
 }
 
+func (m *Message) Tag(key string) (string, bool) {
+	if m.tags == "" {
+		return "", false
+	}
+	for _, tag := range strings.Split(m.tags, ";") {
+		name, value, _ := strings.Cut(tag, "=")
+		if name == key {
+			return unescapeTagValue(value), true
+		}
+	}
+	return "", false
+}
+
+func (m *Message) SetTag(key string, value string) {
+	tag := key
+	if value != "" {
+		tag += "=" + escapeTagValue(value)
+	}
+	if m.tags != "" {
+		tag = ";" + tag
+	}
+	m.tags += tag
+}
+
 func UnmarshalMessage(payload string) *Message {
 	message := Message{}
 
@@ -72,6 +96,41 @@ func MarshalMessage(message *Message) string {
 	return data
 }
 
+var tagValueEscaper = strings.NewReplacer("\\", "\\\\", ";", "\\:", " ", "\\s", "\r", "\\r", "\n", "\\n")
+
+func escapeTagValue(value string) string {
+	return tagValueEscaper.Replace(value)
+}
+
+func unescapeTagValue(value string) string {
+	var builder strings.Builder
+	escaped := false
+	for _, r := range value {
+		if !escaped {
+			if r == '\\' {
+				escaped = true
+			} else {
+				builder.WriteRune(r)
+			}
+			continue
+		}
+		escaped = false
+		switch r {
+		case ':':
+			builder.WriteRune(';')
+		case 's':
+			builder.WriteRune(' ')
+		case 'r':
+			builder.WriteRune('\r')
+		case 'n':
+			builder.WriteRune('\n')
+		default:
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
+
 func nickFromHost(host string) string {
 	index := strings.IndexByte(host, '!')
 	if index == -1 {
@@ -52,3 +52,53 @@ func TestMarshalMessage(t *testing.T) {
 		t.Fatalf("Failed %d/%d tests", fails, len(tests))
 	}
 }
+
+func TestMessageTag(t *testing.T) {
+	tests := map[string]struct {
+		input  string
+		key    string
+		value  string
+		exists bool
+	}{
+		"NoTags": {
+			input:  ":server 001 nick :Welcome",
+			key:    "label",
+			exists: false,
+		},
+		"Value": {
+			input:  "@batch=abc;label=12 :server 311 nick",
+			key:    "label",
+			value:  "12",
+			exists: true,
+		},
+		"NoValue": {
+			input:  "@draft/flag;label=12 PING",
+			key:    "draft/flag",
+			value:  "",
+			exists: true,
+		},
+		"Escaped": {
+			input:  "@msg=a\\sb\\:c\\\\d PING",
+			key:    "msg",
+			value:  "a b;c\\d",
+			exists: true,
+		},
+	}
+
+	fails := 0
+	for testName, test := range tests {
+		t.Logf("Running test %s...", testName)
+
+		value, exists := UnmarshalMessage(test.input).Tag(test.key)
+		if value == test.value && exists == test.exists {
+			t.Logf("  PASS")
+		} else {
+			t.Logf("  FAIL: Expected '%s' (%t), got '%s' (%t)", test.value, test.exists, value, exists)
+			fails++
+		}
+	}
+
+	if fails > 0 {
+		t.Fatalf("Failed %d/%d tests", fails, len(tests))
+	}
+}
@@ -62,6 +62,7 @@ const (
 	RPL_VERSION         string = "351"
 	RPL_WHOREPLY        string = "352"
 	RPL_NAMREPLY        string = "353"
+	RPL_WHOSPCRPL       string = "354"
 	RPL_LINKS           string = "364"
 	RPL_ENDOFLINKS      string = "365"
 	RPL_ENDOFNAMES      string = "366"
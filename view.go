@@ -0,0 +1,71 @@
+package main
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/uniseg"
+)
+
+type view interface {
+	draw(a *Application, x int, y int, width int, height int)
+	handleKey(a *Application, ev *tcell.EventKey) bool
+}
+
+func (a *Application) openView(buffer string, v view) {
+	a.views[buffer] = v
+}
+
+func (a *Application) currentView() view {
+	return a.views[a.pane.buffer]
+}
+
+func (a *Application) closeView() {
+	delete(a.views, a.pane.buffer)
+}
+
+func (a *Application) drawView() {
+	v := a.currentView()
+	if v == nil {
+		return
+	}
+	a.hotspots = a.hotspots[:0]
+	v.draw(a, 0, 1, a.width, a.height-3)
+}
+
+func (a *Application) drawBox(x int, y int, width int, height int, title string, style tcell.Style) {
+	for row := y; row < y+height; row++ {
+		for col := x; col < x+width; col++ {
+			a.screen.SetContent(col, row, ' ', nil, style)
+		}
+	}
+	for col := x + 1; col < x+width-1; col++ {
+		a.screen.SetContent(col, y, '─', nil, style)
+		a.screen.SetContent(col, y+height-1, '─', nil, style)
+	}
+	for row := y + 1; row < y+height-1; row++ {
+		a.screen.SetContent(x, row, '│', nil, style)
+		a.screen.SetContent(x+width-1, row, '│', nil, style)
+	}
+	a.screen.SetContent(x, y, '┌', nil, style)
+	a.screen.SetContent(x+width-1, y, '┐', nil, style)
+	a.screen.SetContent(x, y+height-1, '└', nil, style)
+	a.screen.SetContent(x+width-1, y+height-1, '┘', nil, style)
+
+	if title != "" {
+		a.drawString(x+2, y, " "+title+" ", style.Bold(true))
+	}
+}
+
+func (a *Application) drawStringClip(x int, y int, maxWidth int, text string, style tcell.Style) {
+	col, state := x, -1
+	for rest := text; len(rest) > 0; {
+		var cluster string
+		var boundaries int
+		cluster, rest, boundaries, state = uniseg.StepString(rest, state)
+		width := max(boundaries>>uniseg.ShiftWidth, 1)
+		if col+width > x+maxWidth {
+			return
+		}
+		a.setContent(col, y, cluster, style)
+		col += width
+	}
+}
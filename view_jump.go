@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"github.com/gdamore/tcell/v2"
+	"ribbirc/utils"
+)
+
+type jumpEntry struct {
+	buffer   string
+	position int
+	log      utils.Log
+}
+
+type jumpView struct {
+	title    string
+	entries  []jumpEntry
+	search   string
+	selected int
+	scroll   int
+}
+
+func newJumpView(title string, entries []jumpEntry, search string) *jumpView {
+	return &jumpView{
+		title:    title,
+		entries:  entries,
+		search:   search,
+		selected: len(entries) - 1,
+	}
+}
+
+func (v *jumpView) draw(a *Application, x int, y int, width int, height int) {
+	style := a.theme.style("view")
+	headerStyle := a.theme.apply(style, "view_header")
+
+	for row := y; row < y+height; row++ {
+		for col := x; col < x+width; col++ {
+			a.screen.SetContent(col, row, ' ', nil, style)
+		}
+	}
+
+	header := fmt.Sprintf("%s: %d (Enter to jump, Esc to close)", v.title, len(v.entries))
+	a.drawStringClip(x, y, width, header, headerStyle)
+
+	rows := height - 1
+	if v.selected < v.scroll {
+		v.scroll = v.selected
+	}
+	if v.selected >= v.scroll+rows {
+		v.scroll = v.selected - rows + 1
+	}
+	v.scroll = max(v.scroll, 0)
+
+	for i := 0; i < rows && v.scroll+i < len(v.entries); i++ {
+		entry := v.entries[v.scroll+i]
+		lineStyle := style
+		if v.scroll+i == v.selected {
+			lineStyle = a.theme.apply(style, "view_selected")
+		}
+		buffer := entry.buffer
+		if buffer == "" {
+			buffer = "Status"
+		}
+		text := fmt.Sprintf("%s %s <%s> %s", entry.log.Time.Format("01-02 15:04"), buffer, entry.log.Source, entry.log.Text)
+		a.drawStringClip(x, y+1+i, width, text, lineStyle)
+	}
+}
+
+func (v *jumpView) handleKey(a *Application, ev *tcell.EventKey) bool {
+	switch ev.Key() {
+	case tcell.KeyEscape:
+		return true
+	case tcell.KeyUp:
+		v.selected = max(0, v.selected-1)
+	case tcell.KeyDown:
+		v.selected = min(len(v.entries)-1, v.selected+1)
+	case tcell.KeyPgUp:
+		v.selected = max(0, v.selected-a.height/2)
+	case tcell.KeyPgDn:
+		v.selected = min(len(v.entries)-1, v.selected+a.height/2)
+	case tcell.KeyEnter:
+		if v.selected < 0 {
+			return true
+		}
+		a.closeView()
+		entry := v.entries[v.selected]
+		a.switchBuffer(entry.buffer)
+		if v.search != "" {
+			a.startSearch(v.search, false)
+			for i, position := range a.search.matches {
+				if position == entry.position {
+					a.search.current = i
+				}
+			}
+		}
+		a.jumpToPosition(entry.position)
+		return false
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"github.com/gdamore/tcell/v2"
+	"ribbirc/client"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+type listSort int
+
+const (
+	listSortUsers listSort = iota
+	listSortName
+)
+
+type listView struct {
+	result   *client.ListResult
+	entries  []*client.ListEntry
+	filter   []rune
+	sortBy   listSort
+	selected int
+	scroll   int
+}
+
+func newListView(result *client.ListResult) *listView {
+	v := &listView{result: result}
+	v.refresh()
+	return v
+}
+
+func (v *listView) refresh() {
+	filter := strings.ToLower(string(v.filter))
+	v.entries = make([]*client.ListEntry, 0, len(v.result.Entries))
+	for _, entry := range v.result.Entries {
+		if filter == "" ||
+			strings.Contains(strings.ToLower(entry.Channel), filter) ||
+			strings.Contains(strings.ToLower(entry.Topic), filter) {
+			v.entries = append(v.entries, entry)
+		}
+	}
+
+	sort.SliceStable(v.entries, func(i, j int) bool {
+		if v.sortBy == listSortUsers && v.entries[i].Users != v.entries[j].Users {
+			return v.entries[i].Users > v.entries[j].Users
+		}
+		return strings.ToLower(v.entries[i].Channel) < strings.ToLower(v.entries[j].Channel)
+	})
+
+	v.selected = max(0, min(v.selected, len(v.entries)-1))
+}
+
+func (v *listView) draw(a *Application, x int, y int, width int, height int) {
+	style := a.theme.style("view")
+	headerStyle := a.theme.apply(style, "view_header")
+	selectedStyle := a.theme.apply(style, "view_selected")
+
+	for row := y; row < y+height; row++ {
+		for col := x; col < x+width; col++ {
+			a.screen.SetContent(col, row, ' ', nil, style)
+		}
+	}
+
+	sortName := "users"
+	if v.sortBy == listSortName {
+		sortName = "name"
+	}
+	header := fmt.Sprintf("Channels: %d/%d, sorted by %s (Tab). Filter: %s", len(v.entries), len(v.result.Entries), sortName, string(v.filter))
+	if v.result.TimedOut {
+		header += " (incomplete)"
+	}
+	a.drawStringClip(x, y, width, header, headerStyle)
+
+	nameWidth := 0
+	for _, entry := range v.entries {
+		nameWidth = max(nameWidth, len(entry.Channel))
+	}
+	nameWidth = min(nameWidth, width/3)
+
+	rows := height - 1
+	if v.selected < v.scroll {
+		v.scroll = v.selected
+	}
+	if v.selected >= v.scroll+rows {
+		v.scroll = v.selected - rows + 1
+	}
+	v.scroll = max(v.scroll, 0)
+
+	for i := 0; i < rows && v.scroll+i < len(v.entries); i++ {
+		entry := v.entries[v.scroll+i]
+		lineStyle := style
+		if v.scroll+i == v.selected {
+			lineStyle = selectedStyle
+			for col := x; col < x+width; col++ {
+				a.screen.SetContent(col, y+1+i, ' ', nil, lineStyle)
+			}
+		}
+		a.drawStringClip(x, y+1+i, nameWidth, entry.Channel, lineStyle)
+		a.drawStringClip(x+nameWidth+1, y+1+i, 6, fmt.Sprintf("%6d", entry.Users), lineStyle)
+		a.drawStringClip(x+nameWidth+8, y+1+i, width-nameWidth-8, entry.Topic, lineStyle)
+	}
+}
+
+func (v *listView) handleKey(a *Application, ev *tcell.EventKey) bool {
+	switch ev.Key() {
+	case tcell.KeyEscape:
+		return true
+	case tcell.KeyEnter:
+		if v.selected < 0 || v.selected >= len(v.entries) {
+			return false
+		}
+		a.server.HandleUserInput("/join "+v.entries[v.selected].Channel, a.pane.buffer)
+		return true
+	case tcell.KeyUp:
+		v.selected = max(0, v.selected-1)
+	case tcell.KeyDown:
+		v.selected = max(0, min(len(v.entries)-1, v.selected+1))
+	case tcell.KeyPgUp:
+		v.selected = max(0, v.selected-a.height/2)
+	case tcell.KeyPgDn:
+		v.selected = max(0, min(len(v.entries)-1, v.selected+a.height/2))
+	case tcell.KeyTab:
+		if v.sortBy == listSortUsers {
+			v.sortBy = listSortName
+		} else {
+			v.sortBy = listSortUsers
+		}
+		v.refresh()
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(v.filter) > 0 {
+			v.filter = v.filter[:len(v.filter)-1]
+			v.refresh()
+		}
+	case tcell.KeyRune:
+		if unicode.IsPrint(ev.Rune()) {
+			v.filter = append(v.filter, ev.Rune())
+			v.refresh()
+		}
+	}
+	return false
+}
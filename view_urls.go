@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"github.com/gdamore/tcell/v2"
+	"ribbirc/utils"
+)
+
+type urlEntry struct {
+	jumpEntry
+	url string
+}
+
+type urlView struct {
+	entries  []urlEntry
+	selected int
+	scroll   int
+}
+
+func newURLView(entries []urlEntry) *urlView {
+	return &urlView{entries: entries, selected: len(entries) - 1}
+}
+
+func (a *Application) collectURLs(buffer string) []urlEntry {
+	entries := make([]urlEntry, 0)
+	logger := a.bufferLogs(buffer)
+	if logger == nil {
+		return entries
+	}
+	first, _ := logger.Bounds()
+	for i, log := range logger.GetAllLogs() {
+		text := utils.StripFormatting(log.Text)
+		for _, match := range findURLs(text) {
+			entries = append(entries, urlEntry{jumpEntry{buffer, first + i, log}, text[match[0]:match[1]]})
+		}
+	}
+	return entries
+}
+
+func (a *Application) showURLs() {
+	a.openView(a.pane.buffer, newURLView(a.collectURLs(a.pane.buffer)))
+}
+
+func (v *urlView) draw(a *Application, x int, y int, width int, height int) {
+	style := a.theme.style("view")
+	headerStyle := a.theme.apply(style, "view_header")
+
+	for row := y; row < y+height; row++ {
+		for col := x; col < x+width; col++ {
+			a.screen.SetContent(col, row, ' ', nil, style)
+		}
+	}
+
+	header := fmt.Sprintf("URLs: %d (Enter to open, Tab to jump, Esc to close)", len(v.entries))
+	a.drawStringClip(x, y, width, header, headerStyle)
+
+	rows := height - 1
+	if v.selected < v.scroll {
+		v.scroll = v.selected
+	}
+	if v.selected >= v.scroll+rows {
+		v.scroll = v.selected - rows + 1
+	}
+	v.scroll = max(v.scroll, 0)
+
+	for i := 0; i < rows && v.scroll+i < len(v.entries); i++ {
+		entry := v.entries[v.scroll+i]
+		lineStyle := style
+		if v.scroll+i == v.selected {
+			lineStyle = a.theme.apply(style, "view_selected")
+		}
+		text := fmt.Sprintf("%s <%s> %s", entry.log.Time.Format("01-02 15:04"), entry.log.Source, entry.url)
+		a.drawStringClip(x, y+1+i, width, text, lineStyle)
+		a.addHotspot(x, y+1+i, width, func(a *Application) {
+			a.closeView()
+			a.openURL(entry.url)
+		})
+	}
+}
+
+func (v *urlView) handleKey(a *Application, ev *tcell.EventKey) bool {
+	switch ev.Key() {
+	case tcell.KeyEscape:
+		return true
+	case tcell.KeyUp:
+		v.selected = max(0, v.selected-1)
+	case tcell.KeyDown:
+		v.selected = min(len(v.entries)-1, v.selected+1)
+	case tcell.KeyPgUp:
+		v.selected = max(0, v.selected-a.height/2)
+	case tcell.KeyPgDn:
+		v.selected = min(len(v.entries)-1, v.selected+a.height/2)
+	case tcell.KeyEnter:
+		if v.selected < 0 {
+			return true
+		}
+		a.closeView()
+		a.openURL(v.entries[v.selected].url)
+		return false
+	case tcell.KeyTab:
+		if v.selected < 0 {
+			return true
+		}
+		a.closeView()
+		a.jumpToPosition(v.entries[v.selected].position)
+		return false
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"github.com/gdamore/tcell/v2"
+	"ribbirc/client"
+	"strings"
+	"time"
+)
+
+type whoisView struct {
+	title  string
+	lines  [][2]string
+	scroll int
+}
+
+func newWhoisView(info *client.WhoisInfo) *whoisView {
+	v := &whoisView{title: fmt.Sprintf("WHOIS %s", info.Nick)}
+
+	switch {
+	case info.TimedOut:
+		v.add("", "No complete reply received.")
+	case info.NotFound:
+		v.add("", "No such nick.")
+	}
+	if info.User != "" {
+		v.add("User", fmt.Sprintf("%s!%s@%s", info.Nick, info.User, info.Host))
+	}
+	v.add("Real name", info.RealName)
+	v.add("Account", info.Account)
+	if info.Registered && info.Account == "" {
+		v.add("Account", "registered")
+	}
+	if info.Server != "" {
+		v.add("Server", fmt.Sprintf("%s (%s)", info.Server, info.ServerInfo))
+	}
+	v.add("Away", info.Away)
+	if info.Operator {
+		v.add("Operator", "yes")
+	}
+	if info.Secure {
+		v.add("Secure", "yes")
+	}
+	v.add("Cert FP", info.CertFP)
+	v.add("Actually", info.Actually)
+	v.add("Modes", info.Modes)
+	if info.Idle > 0 {
+		v.add("Idle", info.Idle.String())
+	}
+	if !info.SignOn.IsZero() {
+		v.add("Signed on", info.SignOn.Format(time.RFC1123))
+	}
+	for i, channel := range info.Channels {
+		if i == 0 {
+			v.add("Channels", channel)
+		} else {
+			v.add(" ", channel)
+		}
+	}
+	for _, extra := range info.Extra {
+		v.add("Info", extra)
+	}
+
+	return v
+}
+
+func newWhoView(result *client.WhoResult) *whoisView {
+	v := &whoisView{title: fmt.Sprintf("WHO %s", result.Mask)}
+
+	if result.TimedOut {
+		v.add("", "No complete reply received.")
+	}
+	if len(result.Entries) == 0 {
+		v.add("", "No users found.")
+	}
+	for _, entry := range result.Entries {
+		status := "here"
+		if entry.Away() {
+			status = "away"
+		}
+		text := fmt.Sprintf("%s@%s %s (%s, %s) %s", entry.User, entry.Host, entry.Channel, entry.Server, status, entry.RealName)
+		v.add(entry.Nick, text)
+	}
+
+	return v
+}
+
+func (v *whoisView) add(label string, value string) {
+	if value == "" {
+		return
+	}
+	v.lines = append(v.lines, [2]string{label, value})
+}
+
+func (v *whoisView) draw(a *Application, x int, y int, width int, height int) {
+	labelWidth := 0
+	contentWidth := len(v.title) + 4
+	for _, line := range v.lines {
+		labelWidth = max(labelWidth, len(line[0]))
+	}
+	for _, line := range v.lines {
+		contentWidth = max(contentWidth, labelWidth+len(line[1])+2)
+	}
+
+	boxWidth := min(contentWidth+4, width)
+	boxHeight := min(len(v.lines)+2, height)
+	boxX := x + (width-boxWidth)/2
+	boxY := y + (height-boxHeight)/2
+
+	style := a.theme.style("view")
+	labelStyle := a.theme.apply(style, "view_header")
+	a.drawBox(boxX, boxY, boxWidth, boxHeight, v.title, style)
+
+	v.scroll = max(0, min(v.scroll, len(v.lines)-(boxHeight-2)))
+	for i := 0; i < boxHeight-2; i++ {
+		if v.scroll+i >= len(v.lines) {
+			break
+		}
+		line := v.lines[v.scroll+i]
+		row := boxY + 1 + i
+		a.drawStringClip(boxX+2, row, labelWidth, line[0], labelStyle)
+		a.drawStringClip(boxX+4+labelWidth, row, boxWidth-labelWidth-6, line[1], style)
+	}
+}
+
+func (v *whoisView) handleKey(a *Application, ev *tcell.EventKey) bool {
+	switch ev.Key() {
+	case tcell.KeyEscape, tcell.KeyEnter:
+		return true
+	case tcell.KeyUp:
+		v.scroll--
+	case tcell.KeyDown:
+		v.scroll++
+	case tcell.KeyRune:
+		return strings.ContainsRune("qQ", ev.Rune())
+	}
+	return false
+}
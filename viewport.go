@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"github.com/rivo/uniseg"
+	"ribbirc/utils"
+)
+
+const (
+	logDelim     = 16
+	wheelRows    = 3
+	pageOverlap  = 1
+	moreBelowFmt = " more below (%d) ↓ "
+	notJoinedFmt = "Not joined to %s."
+)
+
+type area struct {
+	x      int
+	y      int
+	width  int
+	height int
+}
+
+func (r area) contains(col int, row int) bool {
+	return col >= r.x && col < r.x+r.width && row >= r.y && row < r.y+r.height
+}
+
+func stringWidth(text string) int {
+	return uniseg.StringWidth(text)
+}
+
+func wrapOffsets(text string, width int) []int {
+	starts := []int{0}
+	if width <= 0 {
+		return starts
+	}
+
+	col, offset, state := 0, 0, -1
+	lastBreak, breakCol := -1, 0
+	for rest := text; len(rest) > 0; {
+		var cluster string
+		var boundaries int
+		cluster, rest, boundaries, state = uniseg.StepString(rest, state)
+		clusterWidth := boundaries >> uniseg.ShiftWidth
+		if col+clusterWidth > width && col > 0 {
+			if lastBreak > starts[len(starts)-1] {
+				starts = append(starts, lastBreak)
+				col -= breakCol
+			} else {
+				starts = append(starts, offset)
+				col = 0
+			}
+			lastBreak = -1
+		}
+		col += clusterWidth
+		offset += len(cluster)
+		if boundaries&uniseg.MaskLine == uniseg.LineCanBreak {
+			lastBreak, breakCol = offset, col
+		}
+	}
+	return starts
+}
+
+func (a *Application) logsArea() area {
+	return area{0, 1, a.logsWidth(), a.height - 3}
+}
+
+func (a *Application) textIndent(log utils.Log) int {
+	switch log.Kind {
+	case utils.LogError, utils.LogStatus:
+		return stringWidth(log.Source) + 2
+	}
+	return logDelim + 2
+}
+
+func (a *Application) logHeight(log utils.Log, width int) int {
+	switch log.Kind {
+	case utils.LogPrivMsg, utils.LogSystem, utils.LogNick, utils.LogError, utils.LogStatus:
+		return len(wrapOffsets(log.Text, width-1-a.textIndent(log)))
+	}
+	return 1
+}
+
+func (a *Application) walkLogs(buffer string, visit func(position int, log utils.Log) bool) {
+	logger := a.bufferLogs(buffer)
+	if logger == nil {
+		return
+	}
+	filter := a.filterFor(buffer)
+	_, end := logger.Bounds()
+
+	offset := 0
+	a.logsRun = a.logsRun[:0]
+	for {
+		a.logsBuffer = logger.GetNLogs(a.logsBuffer, a.height, offset)
+		if len(a.logsBuffer) == 0 {
+			break
+		}
+		first := end - offset - len(a.logsBuffer)
+		for i := len(a.logsBuffer) - 1; i >= 0; i-- {
+			log := a.logsBuffer[i]
+			if filter.smart && log.Filtered {
+				continue
+			}
+			log.Text = utils.StripFormatting(log.Text)
+			if filter.collapse && isMembership(log) {
+				a.logsRun = append(a.logsRun, runEntry{first + i, log})
+				continue
+			}
+			if !a.flushRun(visit) || !visit(first+i, log) {
+				return
+			}
+		}
+		offset += len(a.logsBuffer)
+	}
+	a.flushRun(visit)
+}
+
+func (a *Application) rowsBelow(p *pane, position int) int {
+	width := a.paneViewport(p).width
+	rows := 0
+	a.walkLogs(p.buffer, func(current int, log utils.Log) bool {
+		if current <= position {
+			return false
+		}
+		rows += a.logHeight(log, width)
+		return true
+	})
+	return rows
+}
+
+func (a *Application) drawLogs(p *pane) {
+	a.updateMemberNicks(p.buffer)
+	viewport := a.paneViewport(p)
+	if viewport.width <= 0 || viewport.height <= 0 {
+		return
+	}
+	if !a.bufferOpen(p.buffer) {
+		p.offset = 0
+		a.drawStringClip(viewport.x+1, viewport.y+viewport.height-1, viewport.width-1, fmt.Sprintf(notJoinedFmt, p.buffer), a.theme.style("default"))
+		return
+	}
+
+	row := a.drawLogRows(p, viewport)
+	if row >= viewport.y && p.offset > 0 {
+		p.offset = max(0, p.offset-(row-viewport.y+1))
+		a.clearArea(viewport)
+		a.drawLogRows(p, viewport)
+	}
+
+	if p.offset > 0 {
+		text := fmt.Sprintf(moreBelowFmt, p.offset)
+		a.drawString(viewport.x+viewport.width-stringWidth(text)-1, viewport.y+viewport.height-1, text, a.theme.style("bar"))
+	}
+}
+
+func (a *Application) drawLogRows(p *pane, viewport area) int {
+	a.clip = &viewport
+	defer func() {
+		a.clip = nil
+	}()
+
+	row := viewport.y + viewport.height - 1 + p.offset
+	a.walkLogs(p.buffer, func(position int, log utils.Log) bool {
+		row -= a.drawLog(p, row, position, log)
+		return row >= viewport.y
+	})
+	return row
+}
+
+func (a *Application) clearArea(r area) {
+	style := a.theme.style("default")
+	for row := r.y; row < r.y+r.height; row++ {
+		for col := r.x; col < r.x+r.width; col++ {
+			a.screen.SetContent(col, row, ' ', nil, style)
+		}
+	}
+}
+
+func (a *Application) scrollLogs(rows int) {
+	a.scrollPane(a.pane, rows)
+}
+
+func (a *Application) scrollPane(p *pane, rows int) {
+	p.offset = max(0, p.offset+rows)
+}
+
+func (a *Application) pageRows() int {
+	return max(a.paneViewport(a.pane).height-pageOverlap, 1)
+}
+
+func (a *Application) scrollPageUp() {
+	a.scrollLogs(a.pageRows())
+}
+
+func (a *Application) scrollPageDown() {
+	a.scrollLogs(-a.pageRows())
+}